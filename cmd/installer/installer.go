@@ -30,6 +30,8 @@ var notes []string
 var installBashCompletion bool
 var installZshCompletion bool
 var installFishCompletion bool
+var installPowerShellCompletion bool
+var noModifyPath bool
 
 func main() {
 	flags := pflag.NewFlagSet("cifuzz installer", pflag.ExitOnError)
@@ -38,6 +40,8 @@ func main() {
 	flags.BoolVar(&installBashCompletion, "bash-completion", false, "Install the bash completion script even if SHELL is not bash")
 	flags.BoolVar(&installZshCompletion, "zsh-completion", false, "Install the zsh completion script even if SHELL is not zsh")
 	flags.BoolVar(&installFishCompletion, "fish-completion", false, "Install the fish completion script even if SHELL is not fish")
+	flags.BoolVar(&installPowerShellCompletion, "powershell-completion", false, "Install the PowerShell completion script even if not on Windows (skipped if neither pwsh nor powershell is found)")
+	flags.BoolVar(&noModifyPath, "no-modify-path", false, "Don't modify the PATH environment variable (Windows only)")
 	ignoreCheck := flags.Bool("ignore-installation-check", false, "Doesn't check if a previous installation already exists")
 	cmdutils.ViperMustBindPFlag("verbose", flags.Lookup("verbose"))
 
@@ -85,12 +89,14 @@ See https://github.com/CodeIntelligenceTesting/cifuzz#uninstall`,
 	}
 
 	if runtime.GOOS == "windows" {
-		// TODO: On Windows, users generally don't expect having to fiddle with their PATH. We should update it for
-		//       them, but that requires asking for admin access.
-		log.Notef(`Please add the following directory to your PATH:
+		// PATH is updated automatically via ExtractEmbeddedFiles unless
+		// --no-modify-path was passed or updating it failed, in which
+		// case a note about it was already added to notes above.
+		if noModifyPath {
+			log.Notef(`Please add the following directory to your PATH:
 	%s
-If you haven't already done so.
 `, binDir)
+		}
 	} else {
 		shell := filepath.Base(os.Getenv("SHELL"))
 		var profileName string
@@ -226,10 +232,31 @@ func ExtractEmbeddedFiles(files *embed.FS) error {
 		}
 		shellCompletionInstalled = true
 	}
+	if runtime.GOOS == "windows" || installPowerShellCompletion {
+		err = installPowerShellCompletionScript(installDir, cifuzzPath)
+		if err != nil {
+			return err
+		}
+		shellCompletionInstalled = true
+	}
 	if !shellCompletionInstalled {
 		log.Printf("Not installing shell completion script: Unsupported shell: %s", shell)
 	}
 
+	// On Windows, users generally don't expect having to fiddle with
+	// their PATH, so add binDir to the user-scoped PATH in the registry
+	// directly, instead of just printing a note like we do for the POSIX
+	// shell profiles.
+	if runtime.GOOS == "windows" && !noModifyPath {
+		err = updateWindowsPath(binDir)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf(`Failed to automatically add %s to your PATH: %s
+Please add it manually.
+
+`, binDir, err))
+		}
+	}
+
 	// Support not copying and registering the CMake package.
 
 	// Install and register the CMake package - unless the user
@@ -430,6 +457,80 @@ func installFishCompletionScript(cifuzzPath string) error {
 	return errors.WithStack(err)
 }
 
+// installPowerShellCompletionScript installs the completion script for
+// pwsh (PowerShell 7+) or, failing that, Windows PowerShell, and appends a
+// line sourcing it to the user's $PROFILE if it isn't there already.
+func installPowerShellCompletionScript(targetDir, cifuzzPath string) error {
+	powershell, err := exec.LookPath("pwsh")
+	if err != nil {
+		powershell, err = exec.LookPath("powershell")
+		if err != nil {
+			// Neither pwsh nor Windows PowerShell is installed
+			return nil
+		}
+	}
+
+	completionsDir := filepath.Join(targetDir, "share", "cifuzz", "powershell", "completions")
+	err = os.MkdirAll(completionsDir, 0700)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	completionScriptPath := filepath.Join(completionsDir, "cifuzz.ps1")
+	cmd := exec.Command(powershell, "-NoProfile", "-Command",
+		"'"+cifuzzPath+"' completion powershell | Out-File -Encoding utf8 '"+completionScriptPath+"'")
+	cmd.Stderr = os.Stderr
+	log.Printf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// $PROFILE for the "current user, current host" scope lives under
+	// Documents\PowerShell (pwsh) - we don't distinguish Windows
+	// PowerShell's Documents\WindowsPowerShell here since pwsh is the
+	// actively developed shell going forward.
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	profileDir := filepath.Join(home, "Documents", "PowerShell")
+	err = os.MkdirAll(profileDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	profilePath := filepath.Join(profileDir, "Microsoft.PowerShell_profile.ps1")
+
+	existing, err := os.ReadFile(profilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	sourceLine := fmt.Sprintf(". '%s'", completionScriptPath)
+	if strings.Contains(string(existing), completionScriptPath) {
+		// Already sourced by a previous installation
+		return nil
+	}
+
+	profile, err := os.OpenFile(profilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer profile.Close()
+
+	_, err = fmt.Fprintf(profile, "\n%s\n", sourceLine)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	notes = append(notes, fmt.Sprintf(`To enable command completion, restart PowerShell or run:
+
+    . '%s'
+
+`, profilePath))
+
+	return nil
+}
+
 func oldInstallationExists() (string, bool) {
 	path, err := exec.LookPath("cifuzz")
 	if err != nil {
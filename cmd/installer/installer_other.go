@@ -0,0 +1,9 @@
+//go:build installer && !windows
+
+package main
+
+// updateWindowsPath is a no-op on non-Windows platforms; there, adding
+// binDir to PATH is left to the shell profile notes printed in main.
+func updateWindowsPath(dir string) error {
+	return nil
+}
@@ -0,0 +1,88 @@
+//go:build installer && windows
+
+package main
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	hwndBroadcast      = 0xffff
+	wmSettingChange    = 0x001a
+	smtoAbortIfHung    = 0x0002
+	broadcastTimeoutMs = 5000
+)
+
+// updateWindowsPath appends dir to the current user's PATH environment
+// variable in the registry (HKCU\Environment) if it isn't already there,
+// then broadcasts WM_SETTINGCHANGE so that already-running processes
+// (e.g. Explorer) pick it up without requiring a logoff.
+func updateWindowsPath(dir string) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, `Environment`, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer key.Close()
+
+	path, valueType, err := key.GetStringValue("Path")
+	if err != nil && err != registry.ErrNotExist {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range strings.Split(path, ";") {
+		if strings.EqualFold(strings.TrimRight(entry, `\`), strings.TrimRight(dir, `\`)) {
+			// Already on PATH
+			return nil
+		}
+	}
+
+	if path != "" && !strings.HasSuffix(path, ";") {
+		path += ";"
+	}
+	path += dir
+
+	// Preserve Path's original value type: it's commonly REG_EXPAND_SZ
+	// (so that entries like "%JAVA_HOME%\bin" keep expanding), and
+	// rewriting it as plain REG_SZ would silently break that for every
+	// other entry already on it.
+	if valueType == registry.EXPAND_SZ {
+		err = key.SetExpandStringValue("Path", path)
+	} else {
+		err = key.SetStringValue("Path", path)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	broadcastEnvironmentChange()
+
+	return nil
+}
+
+// broadcastEnvironmentChange notifies other top-level windows that an
+// environment variable changed, so newly-opened terminals pick up the
+// updated PATH without requiring a logoff.
+func broadcastEnvironmentChange() {
+	user32 := windows.NewLazySystemDLL("user32.dll")
+	sendMessageTimeout := user32.NewProc("SendMessageTimeoutW")
+
+	param, err := windows.UTF16PtrFromString("Environment")
+	if err != nil {
+		return
+	}
+
+	sendMessageTimeout.Call(
+		hwndBroadcast,
+		wmSettingChange,
+		0,
+		uintptr(unsafe.Pointer(param)),
+		smtoAbortIfHung,
+		broadcastTimeoutMs,
+		0,
+	)
+}
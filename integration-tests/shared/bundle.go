@@ -149,29 +149,44 @@ func TestBundleLibFuzzer(t *testing.T, dir string, cifuzz string, args ...string
 	assert.NoError(t, err)
 	assert.FileExists(t, coverageProfile)
 
-	if runtime.GOOS == "linux" {
-		// Try to use the artifacts to start a remote run on a mock server
-		projectName := "test-project"
-		artifactsName := "test-artifacts-123"
-		token := "test-token"
-		server := StartMockServer(t, projectName, artifactsName)
-		cmd = executil.Command(cifuzz, "remote-run",
-			"--bundle", bundlePath,
-			"--project", projectName,
-			"--server", server.Address,
-		)
-		cmd.Env, err = envutil.Setenv(os.Environ(), "CIFUZZ_API_TOKEN", token)
-		require.NoError(t, err)
-		cmd.Dir = dir
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		t.Logf("Command: %s", cmd.String())
-		err = cmd.Run()
-		require.NoError(t, err)
-		require.FileExists(t, bundlePath)
-		require.True(t, server.ArtifactsUploaded)
-		require.True(t, server.RunStarted)
-	}
+	testRemoteRun(t, dir, cifuzz, bundlePath, metadata)
+}
+
+// testRemoteRun uploads bundlePath to a LocalArtifactRegistry via
+// "cifuzz remote-run" and verifies the server received the same
+// branch/commit/docker-image/env fields the client's own bundle.yaml
+// (localMetadata) already asserted on.
+func testRemoteRun(t *testing.T, dir, cifuzz, bundlePath string, localMetadata *archive.Metadata) {
+	t.Helper()
+
+	projectName := "test-project"
+	artifactsName := "test-artifacts-123"
+	token := "test-token"
+	server := NewLocalArtifactRegistry(t, projectName, artifactsName, token)
+
+	cmd := executil.Command(cifuzz, "remote-run",
+		"--bundle", bundlePath,
+		"--project", projectName,
+		"--server", server.Address,
+	)
+	var err error
+	cmd.Env, err = envutil.Setenv(os.Environ(), "CIFUZZ_API_TOKEN", token)
+	require.NoError(t, err)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	t.Logf("Command: %s", cmd.String())
+	err = cmd.Run()
+	require.NoError(t, err)
+	require.True(t, server.ArtifactsUploaded)
+	require.True(t, server.RunStarted)
+
+	received := server.Received()
+	require.NotNil(t, received)
+	assert.Equal(t, localMetadata.CodeRevision.Git.Branch, received.CodeRevision.Git.Branch)
+	assert.Equal(t, localMetadata.CodeRevision.Git.Commit, received.CodeRevision.Git.Commit)
+	assert.Equal(t, localMetadata.RunEnvironment.Docker, received.RunEnvironment.Docker)
+	assert.Equal(t, localMetadata.Fuzzers[0].EngineOptions.Env, received.Fuzzers[0].EngineOptions.Env)
 }
 
 func TestBundleMaven(t *testing.T, dir string, cifuzz string, args ...string) {
@@ -267,6 +282,8 @@ func TestBundleMaven(t *testing.T, dir string, cifuzz string, args ...string) {
 	content, err = os.ReadFile(manifestFilePath)
 	require.NoError(t, err)
 	assert.Equal(t, "Jazzer-Fuzz-Target-Class: com.example.FuzzTestCase\n", string(content))
+
+	testRemoteRun(t, dir, cifuzz, bundlePath, metadata)
 }
 
 func TestBundleGradle(t *testing.T, dir string, cifuzz string, args ...string) {
@@ -362,6 +379,8 @@ func TestBundleGradle(t *testing.T, dir string, cifuzz string, args ...string) {
 	content, err = os.ReadFile(manifestFilePath)
 	require.NoError(t, err)
 	assert.Equal(t, "Jazzer-Fuzz-Target-Class: com.example.FuzzTestCase\n", string(content))
+
+	testRemoteRun(t, dir, cifuzz, bundlePath, metadata)
 }
 
 func TestRunBundle(t *testing.T, dir string, cifuzz string, bundlePath string, args ...string) (*archive.Metadata, string) {
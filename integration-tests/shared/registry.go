@@ -0,0 +1,340 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+)
+
+// LocalArtifactRegistry is an in-process stand-in for CI Sense's artifact
+// upload and remote-run trigger endpoints, implementing enough of the
+// real protocol (multipart upload, resumable chunked upload, and an
+// OAuth2 token exchange in front of both) that "cifuzz remote-run" can be
+// driven against a real HTTP server in integration tests instead of
+// having its network calls mocked away - the same way buildkit wires a
+// local registry into its own integration harness.
+type LocalArtifactRegistry struct {
+	*httptest.Server
+	Address string
+
+	projectName   string
+	artifactsName string
+	token         string
+	accessToken   string
+	storageDir    string
+
+	mu                sync.Mutex
+	resumableUploads  map[string]*resumableUpload
+	ArtifactsUploaded bool
+	RunStarted        bool
+	received          *archive.Metadata
+}
+
+type resumableUpload struct {
+	file *os.File
+	path string
+}
+
+// NewLocalArtifactRegistry starts the registry and returns it. It's shut
+// down, and its storage directory removed, automatically when t's test
+// finishes.
+func NewLocalArtifactRegistry(t *testing.T, projectName, artifactsName, token string) *LocalArtifactRegistry {
+	t.Helper()
+
+	storageDir, err := os.MkdirTemp("", "cifuzz-local-artifact-registry-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(storageDir) })
+
+	r := &LocalArtifactRegistry{
+		projectName:      projectName,
+		artifactsName:    artifactsName,
+		token:            token,
+		accessToken:      "mock-access-token-" + artifactsName,
+		storageDir:       storageDir,
+		resumableUploads: map[string]*resumableUpload{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/oauth/token", r.handleToken)
+	mux.HandleFunc(fmt.Sprintf("/v2/projects/%s/artifacts", projectName), r.handleUpload)
+	mux.HandleFunc(fmt.Sprintf("/v2/projects/%s/artifacts/resumable", projectName), r.handleResumableInit)
+	mux.HandleFunc(fmt.Sprintf("/v2/projects/%s/artifacts/resumable/", projectName), r.handleResumableChunk)
+	mux.HandleFunc(fmt.Sprintf("/v2/projects/%s/runs", projectName), r.handleRun)
+
+	r.Server = httptest.NewServer(mux)
+	t.Cleanup(r.Server.Close)
+	r.Address = strings.TrimPrefix(r.Server.URL, "http://")
+
+	return r
+}
+
+// Received returns the archive.Metadata the registry unpacked from the
+// last successfully uploaded bundle, or nil if no upload has completed
+// yet.
+func (r *LocalArtifactRegistry) Received() *archive.Metadata {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.received
+}
+
+func (r *LocalArtifactRegistry) checkAuth(w http.ResponseWriter, req *http.Request) bool {
+	if req.Header.Get("Authorization") != "Bearer "+r.accessToken {
+		http.Error(w, "invalid or missing access token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleToken simulates the OAuth2 token-exchange path every other
+// endpoint requires a bearer token from: the client trades its long-lived
+// CIFUZZ_API_TOKEN for a short-lived access token, the way the real
+// backend does.
+func (r *LocalArtifactRegistry) handleToken(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.FormValue("grant_type") != "api_token" || req.FormValue("api_token") != r.token {
+		http.Error(w, "invalid api token", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": r.accessToken,
+		"token_type":   "Bearer",
+	})
+}
+
+// handleUpload accepts a one-shot multipart artifact upload.
+func (r *LocalArtifactRegistry) handleUpload(w http.ResponseWriter, req *http.Request) {
+	if !r.checkAuth(w, req) {
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	artifactID, err := r.store(file, req.FormValue("sha256"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"artifact": artifactID})
+}
+
+// handleResumableInit opens a new resumable upload session and returns
+// its ID, which the client then PUTs chunks to.
+func (r *LocalArtifactRegistry) handleResumableInit(w http.ResponseWriter, req *http.Request) {
+	if !r.checkAuth(w, req) {
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.mu.Lock()
+	uploadID := fmt.Sprintf("upload-%d", len(r.resumableUploads)+1)
+	r.mu.Unlock()
+
+	path := filepath.Join(r.storageDir, uploadID+".part")
+	f, err := os.Create(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r.mu.Lock()
+	r.resumableUploads[uploadID] = &resumableUpload{file: f, path: path}
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"uploadId": uploadID})
+}
+
+// handleResumableChunk appends one chunk of a resumable upload, keyed by
+// a "bytes <start>-<end>/<total>" Content-Range header, and finalizes the
+// upload - verifying its SHA-256 and unpacking its metadata, exactly like
+// the one-shot path does - once the last chunk arrives.
+func (r *LocalArtifactRegistry) handleResumableChunk(w http.ResponseWriter, req *http.Request) {
+	if !r.checkAuth(w, req) {
+		return
+	}
+	if req.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uploadID := strings.TrimPrefix(req.URL.Path, fmt.Sprintf("/v2/projects/%s/artifacts/resumable/", r.projectName))
+
+	r.mu.Lock()
+	upload, ok := r.resumableUploads[uploadID]
+	r.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown upload id", http.StatusNotFound)
+		return
+	}
+
+	start, _, total, err := parseContentRange(req.Header.Get("Content-Range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	chunk, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := upload.file.WriteAt(chunk, start); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if start+int64(len(chunk)) < total {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// This was the last chunk: finalize the upload.
+	_ = upload.file.Close()
+	f, err := os.Open(upload.path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	artifactID, err := r.store(f, req.URL.Query().Get("sha256"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	delete(r.resumableUploads, uploadID)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"artifact": artifactID})
+}
+
+// parseContentRange parses a "bytes <start>-<end>/<total>" header value.
+func parseContentRange(header string) (start, end, total int64, err error) {
+	header = strings.TrimPrefix(header, "bytes ")
+	parts := strings.SplitN(header, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, 0, errors.Errorf("malformed Content-Range header %q", header)
+	}
+	total, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, errors.WithStack(err)
+	}
+	rangeParts := strings.SplitN(parts[0], "-", 2)
+	if len(rangeParts) != 2 {
+		return 0, 0, 0, errors.Errorf("malformed Content-Range header %q", header)
+	}
+	start, err = strconv.ParseInt(rangeParts[0], 10, 64)
+	if err != nil {
+		return 0, 0, 0, errors.WithStack(err)
+	}
+	end, err = strconv.ParseInt(rangeParts[1], 10, 64)
+	if err != nil {
+		return 0, 0, 0, errors.WithStack(err)
+	}
+	return start, end, total, nil
+}
+
+// store persists the uploaded bundle under storageDir, verifies its
+// SHA-256 against announcedSHA256 (if the client sent one), unpacks its
+// bundle.yaml into r.received, and returns an opaque artifact ID.
+func (r *LocalArtifactRegistry) store(content io.Reader, announcedSHA256 string) (string, error) {
+	bundlePath := filepath.Join(r.storageDir, r.artifactsName+".tar.gz")
+	out, err := os.Create(bundlePath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, h), content)
+	_ = out.Close()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	actualSHA256 := hex.EncodeToString(h.Sum(nil))
+	if announcedSHA256 != "" && announcedSHA256 != actualSHA256 {
+		return "", errors.Errorf("client-announced sha256 %s does not match uploaded content's %s", announcedSHA256, actualSHA256)
+	}
+
+	archiveDir := filepath.Join(r.storageDir, "extracted")
+	err = archive.ExtractArchiveForTestsOnly(bundlePath, archiveDir)
+	if err != nil {
+		return "", err
+	}
+	metadataYAML, err := os.ReadFile(filepath.Join(archiveDir, "bundle.yaml"))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	metadata := &archive.Metadata{}
+	if err := yaml.Unmarshal(metadataYAML, metadata); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	r.mu.Lock()
+	r.received = metadata
+	r.ArtifactsUploaded = true
+	r.mu.Unlock()
+
+	return r.artifactsName, nil
+}
+
+// handleRun simulates triggering a remote run against a previously
+// uploaded artifact.
+func (r *LocalArtifactRegistry) handleRun(w http.ResponseWriter, req *http.Request) {
+	if !r.checkAuth(w, req) {
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.mu.Lock()
+	r.RunStarted = true
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"run": "mock-run-id"})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// DeviceCodeResponse is returned by the device authorization endpoint, as
+// defined by RFC 8628 section 3.2.
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// RequestDeviceCode starts the OAuth 2.0 device authorization grant flow
+// (RFC 8628) by requesting a device code and the user code the caller
+// should display to the user.
+func (client *APIClient) RequestDeviceCode() (*DeviceCodeResponse, error) {
+	resp, err := client.sendRequest("POST", "oauth/device/code", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, responseToAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var deviceCode DeviceCodeResponse
+	err = json.Unmarshal(body, &deviceCode)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &deviceCode, nil
+}
+
+type deviceTokenRequest struct {
+	GrantType  string `json:"grant_type"`
+	DeviceCode string `json:"device_code"`
+}
+
+// DeviceTokenResponse is returned by the token endpoint while polling for
+// completion of the device authorization grant flow. Error is one of the
+// RFC 8628 section 3.5 error codes ("authorization_pending", "slow_down",
+// "access_denied", "expired_token") when AccessToken is empty.
+type DeviceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+}
+
+// PollDeviceToken makes a single poll of the token endpoint for the given
+// device code. The caller is responsible for waiting Interval seconds
+// between calls and for interpreting the Error field of the result, per
+// RFC 8628 section 3.5 - a non-empty Error doesn't necessarily mean the
+// request failed, e.g. "authorization_pending" just means to keep polling.
+func (client *APIClient) PollDeviceToken(deviceCode string) (*DeviceTokenResponse, error) {
+	reqBody, err := json.Marshal(&deviceTokenRequest{
+		GrantType:  "urn:ietf:params:oauth:grant-type:device_code",
+		DeviceCode: deviceCode,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	resp, err := client.sendRequest("POST", "oauth/token", bytes.NewReader(reqBody), "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var tokenResponse DeviceTokenResponse
+	err = json.Unmarshal(body, &tokenResponse)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if resp.StatusCode != 200 && tokenResponse.Error == "" {
+		return nil, responseToAPIError(resp)
+	}
+
+	return &tokenResponse, nil
+}
+
+// ExchangeDeviceToken exchanges the short-lived OAuth access token
+// obtained from the device authorization grant flow for a long-lived CI
+// Fuzz API token that can be stored and reused the same way as a token
+// generated on the dashboard.
+func (client *APIClient) ExchangeDeviceToken(accessToken string) (string, error) {
+	resp, err := client.sendRequest("POST", "v1/tokens:exchange", nil, accessToken)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", responseToAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var tokenResponse struct {
+		Token string `json:"token"`
+	}
+	err = json.Unmarshal(body, &tokenResponse)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return tokenResponse.Token, nil
+}
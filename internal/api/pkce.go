@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+type authorizationCodeRequest struct {
+	GrantType    string `json:"grant_type"`
+	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+}
+
+// ExchangeAuthorizationCode exchanges an OAuth 2.0 authorization code
+// obtained via the browser-based SSO login flow for a long-lived CI Fuzz
+// API token, presenting the PKCE (RFC 7636) code_verifier that matches
+// the code_challenge sent when the authorization request was opened in
+// the browser. redirectURI must be the same loopback callback URL that
+// was passed to the authorization request, as required by RFC 6749
+// section 4.1.3 to guard against authorization code injection.
+func (client *APIClient) ExchangeAuthorizationCode(code, verifier, redirectURI string) (string, error) {
+	reqBody, err := json.Marshal(&authorizationCodeRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		CodeVerifier: verifier,
+		RedirectURI:  redirectURI,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	resp, err := client.sendRequest("POST", "oauth/token", bytes.NewReader(reqBody), "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", responseToAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var tokenResponse struct {
+		Token string `json:"token"`
+	}
+	err = json.Unmarshal(body, &tokenResponse)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return tokenResponse.Token, nil
+}
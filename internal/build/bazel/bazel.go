@@ -0,0 +1,220 @@
+// Package bazel builds fuzz tests defined via rules_fuzzing's
+// cc_fuzz_test and java_fuzz_test rules, the same way internal/build/cmake
+// builds CMake-based fuzz tests.
+package bazel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// defaultEngine is the fuzzing engine BuildForRun assumes when
+// BuilderOptions.Engine isn't set, matching the engine cmake.Builder
+// defaults to.
+const defaultEngine = "libfuzzer"
+
+type BuilderOptions struct {
+	ProjectDir string
+	Args       []string
+	NumJobs    uint
+	// Engine is the fuzzing engine to instrument cc_fuzz_test targets
+	// with, e.g. "libfuzzer" or "honggfuzz". Defaults to defaultEngine.
+	Engine string
+	// Sanitizers are the sanitizers to instrument cc_fuzz_test targets
+	// with, e.g. []string{"address", "undefined"}.
+	Sanitizers []string
+	Stdout     io.Writer
+	Stderr     io.Writer
+	TempDir    string
+	Verbose    bool
+}
+
+func (opts *BuilderOptions) Validate() error {
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+type Builder struct {
+	*BuilderOptions
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{BuilderOptions: opts}, nil
+}
+
+// ListFuzzTests enumerates the cc_fuzz_test and java_fuzz_test targets
+// rules_fuzzing defines anywhere in the workspace.
+func (b *Builder) ListFuzzTests() ([]string, error) {
+	cmd := exec.Command("bazel", "query", `kind("cc_fuzz_test rule|java_fuzz_test rule", //...)`)
+	cmd.Dir = b.ProjectDir
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	return nonEmptyLines(out), nil
+}
+
+// engine returns the fuzzing engine to instrument cc_fuzz_test targets
+// with, defaulting to defaultEngine if BuilderOptions.Engine is unset.
+func (b *Builder) engine() string {
+	if b.Engine != "" {
+		return b.Engine
+	}
+	return defaultEngine
+}
+
+// configName returns the bazel --config name BuildForRun builds with,
+// e.g. "cifuzz-libfuzzer-address", so that a project's .bazelrc can pin
+// additional per-config settings the same way rules_fuzzing projects
+// already key their own asan-fuzzer config off of --config.
+func configName(engine string, sanitizers []string) string {
+	return strings.Join(append([]string{"cifuzz", engine}, sanitizers...), "-")
+}
+
+// sanitizerFlags translates engine and sanitizers into the --copt/
+// --linkopt flags that instrument a cc_fuzz_test target with them,
+// mirroring how cmake.Builder derives its CMAKE_C_FLAGS/CMAKE_CXX_FLAGS
+// from the same two fields.
+func sanitizerFlags(engine string, sanitizers []string) []string {
+	var flags []string
+	switch engine {
+	case "honggfuzz":
+		flags = append(flags, "--copt=-fsanitize-coverage=trace-pc-guard")
+	default:
+		flags = append(flags, "--copt=-fsanitize=fuzzer-no-link", "--linkopt=-fsanitize=fuzzer")
+	}
+	for _, sanitizer := range sanitizers {
+		switch sanitizer {
+		case "address":
+			flags = append(flags, "--copt=-fsanitize=address", "--linkopt=-fsanitize=address")
+		case "undefined":
+			flags = append(flags, "--copt=-fsanitize=undefined", "--linkopt=-fsanitize=undefined")
+		}
+	}
+	return flags
+}
+
+// BuildForRun builds each of fuzzTests with the engine and sanitizers
+// BuilderOptions was configured with and returns one build.Result per
+// target. Executable points to a wrapper script generated via "bazel run
+// --script_path", which runs the target binary with its runfiles tree
+// already set up, so that the resulting build.Result can be driven by
+// pkg/runner/libfuzzer the same way a CMake build.Result is. RuntimeDeps
+// is populated from the target's runfiles via "bazel cquery
+// --output=files", so that the Jazzer bundler's assembleArtifacts
+// produces the same runtime_deps/ layout it does for other build
+// systems.
+func (b *Builder) BuildForRun(fuzzTests []string) ([]*build.Result, error) {
+	var results []*build.Result
+	for _, fuzzTest := range fuzzTests {
+		result, err := b.buildForRun(fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (b *Builder) buildForRun(fuzzTest string) (*build.Result, error) {
+	scriptName := strings.NewReplacer("/", "_", ":", "_").Replace(strings.TrimPrefix(fuzzTest, "//"))
+	scriptPath := filepath.Join(b.TempDir, scriptName)
+
+	engine := b.engine()
+	args := []string{"run", "--config=" + configName(engine, b.Sanitizers)}
+	args = append(args, sanitizerFlags(engine, b.Sanitizers)...)
+	args = append(args, "--script_path="+scriptPath)
+	if b.NumJobs != 0 {
+		args = append(args, fmt.Sprintf("--jobs=%d", b.NumJobs))
+	}
+	if b.Verbose {
+		args = append(args, "-s")
+	}
+	args = append(args, fuzzTest)
+	args = append(args, b.Args...)
+
+	cmd := exec.Command("bazel", args...)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err := cmd.Run()
+	if err != nil {
+		// It's expected that bazel might fail due to user configuration,
+		// so we print the error without the stack trace.
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	runtimeDeps, err := b.runtimeDeps(fuzzTest)
+	if err != nil {
+		return nil, err
+	}
+
+	generatedCorpus := filepath.Join(b.ProjectDir, ".cifuzz-corpus", scriptName)
+
+	return &build.Result{
+		Name:            fuzzTest,
+		Executable:      scriptPath,
+		GeneratedCorpus: generatedCorpus,
+		BuildDir:        b.ProjectDir,
+		ProjectDir:      b.ProjectDir,
+		Engine:          engine,
+		Sanitizers:      b.Sanitizers,
+		RuntimeDeps:     runtimeDeps,
+	}, nil
+}
+
+// runtimeDeps returns the canonical paths of every file in fuzzTest's
+// runfiles tree, which the wrapper script produced by buildForRun needs
+// alongside it to run outside of "bazel run".
+func (b *Builder) runtimeDeps(fuzzTest string) ([]string, error) {
+	cmd := exec.Command("bazel", "cquery", "--output=files", fuzzTest)
+	cmd.Dir = b.ProjectDir
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	return nonEmptyLines(out), nil
+}
+
+func nonEmptyLines(out []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,59 @@
+// Package build defines the types shared by every build system
+// integration (internal/build/cmake, internal/build/maven,
+// internal/build/bazel, internal/build/gotest, internal/build/golibfuzzer):
+// the Result a concrete build produces and, since chunk3-3, the Build
+// interface that lets callers work with any of them without knowing
+// which build system is in play.
+package build
+
+import "os"
+
+// Result describes a fuzz target that has been built and is ready to
+// run, independent of which build system produced it.
+type Result struct {
+	// Name identifies the fuzz target, e.g. a CMake target name or a
+	// Java fuzz test's fully qualified class name.
+	Name string
+	// Executable is the path to the fuzz target's binary. Empty for
+	// build systems (e.g. Maven/Gradle) that run the target via a JVM
+	// rather than a standalone executable.
+	Executable string
+	// BuildDir is the directory the build system wrote its build
+	// artifacts to.
+	BuildDir string
+	// ProjectDir is the root directory of the project that was built.
+	ProjectDir string
+	// SeedCorpus is the path to the fuzz target's seed corpus directory.
+	SeedCorpus string
+	// GeneratedCorpus is the path to the directory the fuzz target's
+	// generated corpus should be stored in and read back from.
+	GeneratedCorpus string
+	// RuntimeDeps lists the paths of the fuzz target's runtime
+	// dependencies (shared libraries or classpath entries), to be made
+	// available to it when it's run, e.g. via LD_LIBRARY_PATH or
+	// -cp.
+	RuntimeDeps []string
+	// Engine is the fuzzing engine the target was built for, e.g.
+	// "libfuzzer" or "jazzer".
+	Engine string
+	// Sanitizers lists the sanitizers the target was built with.
+	Sanitizers []string
+	// Runtime identifies the language runtime Executable needs to be
+	// invoked through, e.g. "python" for an atheris wrapper script.
+	// Empty for build systems whose Executable is already a native
+	// binary or JVM target that the runner knows how to run directly.
+	Runtime string
+	// Dictionary is the path to the fuzz target's own dictionary, for
+	// build systems (e.g. cargo-fuzz) that keep one per target rather
+	// than sharing a single project-wide dictionary. Empty if the
+	// target has none of its own.
+	Dictionary string
+}
+
+// CommonBuildEnv returns the base environment every builder runs its
+// build commands in: the current process's environment, which already
+// carries whatever CC/CXX/CFLAGS overrides the user or CI system set for
+// the build to pick up.
+func CommonBuildEnv() ([]string, error) {
+	return os.Environ(), nil
+}
@@ -38,7 +38,42 @@ type BuilderOptions struct {
 	Stdout     io.Writer
 	Stderr     io.Writer
 
+	// Generator is the CMake generator to configure the project with,
+	// e.g. "Ninja Multi-Config" or "Xcode". Empty lets CMake pick its
+	// platform default (Unix Makefiles on Linux/macOS, MSBuild on
+	// Windows), exactly as before this field existed.
+	Generator string
+	// Config is the build configuration ("build type") fuzz tests are
+	// built and run with. Defaults to cmakeBuildConfiguration
+	// ("RelWithDebInfo") if empty.
+	Config string
+
 	FindRuntimeDeps bool
+
+	// EventWriter, if set, receives a newline-delimited JSON stream of
+	// build.Events (ConfigureStart, Diagnostic, TargetBuilt, BuildFailed,
+	// RuntimeDepsResolved) describing Configure/Build's progress, for IDE
+	// integrations that want structured progress instead of scraping
+	// Stdout/Stderr.
+	EventWriter io.Writer
+}
+
+// isMultiConfigGenerator reports whether generator produces a build tree
+// that holds every configuration side by side (Ninja Multi-Config,
+// Xcode, the Visual Studio generators), as opposed to a single-config
+// generator (Unix Makefiles, single-config Ninja) that bakes
+// CMAKE_BUILD_TYPE in at configure time.
+func isMultiConfigGenerator(generator string) bool {
+	switch {
+	case generator == "Ninja Multi-Config":
+		return true
+	case generator == "Xcode":
+		return true
+	case strings.HasPrefix(generator, "Visual Studio"):
+		return true
+	default:
+		return false
+	}
 }
 
 func (opts *BuilderOptions) Validate() error {
@@ -87,21 +122,39 @@ func (b *Builder) Opts() *BuilderOptions {
 
 func (b *Builder) BuildDir() string {
 	// Note: Invoking CMake on the same build directory with different cache
-	// variables is a no-op. For this reason, we have to encode all choices made
-	// for the cache variables below in the path to the build directory.
-	// Currently, this includes the fuzzing engine and the choice of sanitizers.
+	// variables, or a different generator, is a no-op at best and a hard
+	// error at worst (CMake refuses to switch a build tree's generator in
+	// place). For this reason, we have to encode all choices made for the
+	// cache variables below, plus the generator, in the path to the build
+	// directory. Currently, this includes the fuzzing engine, the choice
+	// of sanitizers, and the generator.
 	sanitizersSegment := strings.Join(b.Sanitizers, "+")
 	if sanitizersSegment == "" {
 		sanitizersSegment = "none"
 	}
+	generatorSegment := strings.ReplaceAll(b.Generator, " ", "-")
+	if generatorSegment == "" {
+		generatorSegment = "default-generator"
+	}
 	return filepath.Join(
 		b.ProjectDir,
 		".cifuzz-build",
 		b.Engine,
 		sanitizersSegment,
+		generatorSegment,
 	)
 }
 
+// config returns the CMake build configuration fuzz tests are built and
+// run with: BuilderOptions.Config if set, or cmakeBuildConfiguration
+// otherwise.
+func (b *Builder) config() string {
+	if b.Config != "" {
+		return b.Config
+	}
+	return cmakeBuildConfiguration
+}
+
 // Configure calls cmake to "Generate a project buildsystem" (that's the
 // phrasing used by the CMake man page).
 // Note: This is usually a no-op after the directory has been created once,
@@ -112,12 +165,38 @@ func (b *Builder) BuildDir() string {
 // we either get a helpful error message or the build step will succeed if
 // the user fixed the issue in the meantime.
 func (b *Builder) Configure() error {
+	// Drop a codemodel-v2 file-api query so that this (re-)configure
+	// writes the reply AffectedFuzzTests needs to answer "--only-affected".
+	err := writeFileAPIQuery(b.BuildDir())
+	if err != nil {
+		return err
+	}
+	_ = build.EmitEvent(b.EventWriter, build.Event{Kind: build.EventConfigureStart})
+
 	cacheArgs := []string{
-		"-DCMAKE_BUILD_TYPE=" + cmakeBuildConfiguration,
+		"-DCMAKE_BUILD_TYPE=" + b.config(),
 		"-DCIFUZZ_ENGINE=" + b.Engine,
 		"-DCIFUZZ_SANITIZERS=" + strings.Join(b.Sanitizers, ";"),
 		"-DCIFUZZ_TESTING:BOOL=ON",
 	}
+	// honggfuzz and AFL++ fuzz tests are built with that engine's own
+	// instrumenting compiler wrapper instead of a Clang sanitizer flag,
+	// and need their engine's runtime archive linked in.
+	if compiler := engineCompiler(b.Engine); compiler != "" {
+		cacheArgs = append(cacheArgs, "-DCIFUZZ_ENGINE_COMPILER="+compiler)
+	}
+	if linkFlags := engineLinkFlags(b.Engine); linkFlags != "" {
+		cacheArgs = append(cacheArgs, "-DCIFUZZ_ENGINE_LINK_FLAGS="+linkFlags)
+	}
+	if isMultiConfigGenerator(b.Generator) {
+		// Multi-config generators (Ninja Multi-Config, Xcode, Visual
+		// Studio) choose their configuration per `cmake --build
+		// --config` invocation instead of at configure time, and
+		// ignore CMAKE_BUILD_TYPE; restricting
+		// CMAKE_CONFIGURATION_TYPES to the one we build keeps CI from
+		// also configuring the ones we'll never invoke.
+		cacheArgs = append(cacheArgs, "-DCMAKE_CONFIGURATION_TYPES="+b.config())
+	}
 	if viper.GetBool("verbose") {
 		cacheArgs = append(cacheArgs, "-DCMAKE_VERBOSE_MAKEFILE:BOOL=ON")
 	}
@@ -134,7 +213,11 @@ func (b *Builder) Configure() error {
 		cacheArgs = append(cacheArgs, "-DCMAKE_BUILD_RPATH_USE_ORIGIN:BOOL=ON")
 	}
 
-	cmd := exec.Command("cmake", append(cacheArgs, b.ProjectDir)...)
+	args := cacheArgs
+	if b.Generator != "" {
+		args = append([]string{"-G", b.Generator}, args...)
+	}
+	cmd := exec.Command("cmake", append(args, b.ProjectDir)...)
 	// Redirect the build command's stdout to stderr to only have
 	// reports printed to stdout
 	cmd.Stdout = b.Stderr
@@ -143,7 +226,7 @@ func (b *Builder) Configure() error {
 	cmd.Dir = b.BuildDir()
 	log.Debugf("Working directory: %s", cmd.Dir)
 	log.Debugf("Command: %s", cmd.String())
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
 		// It's expected that cmake might fail due to user configuration,
 		// so we print the error without the stack trace.
@@ -163,7 +246,7 @@ func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
 
 	flags := append([]string{
 		"--build", buildDir,
-		"--config", cmakeBuildConfiguration,
+		"--config", b.config(),
 		"--target"}, fuzzTests...)
 
 	if b.Parallel.Enabled {
@@ -172,12 +255,17 @@ func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
 			flags = append(flags, fmt.Sprint(b.Parallel.NumJobs))
 		}
 	}
+	// Pass "-k 0" through to the underlying build tool (Make or Ninja
+	// both understand it) so that a failing target doesn't stop the
+	// whole build: buildEventWriter can then report every diagnostic in
+	// one run instead of just the first target that happened to fail.
+	flags = append(flags, "--", "-k", "0")
 
 	cmd := exec.Command("cmake", flags...)
 	// Redirect the build command's stdout to stderr to only have
 	// reports printed to stdout
-	cmd.Stdout = b.Stderr
-	cmd.Stderr = b.Stderr
+	cmd.Stdout = b.buildOutput(b.Stderr)
+	cmd.Stderr = b.buildOutput(b.Stderr)
 	cmd.Env = b.env
 	log.Debugf("Command: %s", cmd.String())
 	err = cmd.Run()
@@ -186,6 +274,7 @@ func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
 		// so we print the error without the stack trace.
 		err = cmdutils.WrapExecError(err, cmd)
 		log.Error(err)
+		_ = build.EmitEvent(b.EventWriter, build.Event{Kind: build.EventBuildFailed, Error: err.Error()})
 		return nil, cmdutils.ErrSilent
 	}
 
@@ -205,6 +294,11 @@ func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
 			if err != nil {
 				return nil, err
 			}
+			_ = build.EmitEvent(b.EventWriter, build.Event{
+				Kind:        build.EventRuntimeDepsResolved,
+				FuzzTest:    fuzzTest,
+				RuntimeDeps: runtimeDeps,
+			})
 		}
 		generatedCorpus := filepath.Join(b.ProjectDir, ".cifuzz-corpus", fuzzTest)
 		results[fuzzTest] = &build.Result{
@@ -223,6 +317,38 @@ func (b *Builder) Build(fuzzTests []string) (map[string]*build.Result, error) {
 	return results, nil
 }
 
+// BuildForCoverage builds fuzzTests the same way Build does, but with
+// the "coverage" sanitizer instead of whichever sanitizers the Builder
+// was configured with, so that replaying their corpora through the
+// resulting binaries produces Clang source-based coverage data instead
+// of binaries meant for fuzzing. Since BuildDir() already segments its
+// path by Sanitizers, this builds into its own tree without disturbing a
+// fuzzing build that might already exist for the same project and
+// engine - the "reconfigure into a parallel build directory" that a
+// coverage build needs.
+func (b *Builder) BuildForCoverage(fuzzTests []string) (map[string]*build.Result, error) {
+	sanitizers := b.Sanitizers
+	b.Sanitizers = []string{"coverage"}
+	defer func() { b.Sanitizers = sanitizers }()
+
+	err := b.Configure()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Build(fuzzTests)
+}
+
+// CoverageBuild is BuildForCoverage for a single fuzz test, for callers
+// (internal/cmd/coverage) that only build one target at a time.
+func (b *Builder) CoverageBuild(fuzzTest string) (*build.Result, error) {
+	results, err := b.BuildForCoverage([]string{fuzzTest})
+	if err != nil {
+		return nil, err
+	}
+	return results[fuzzTest], nil
+}
+
 // findFuzzTestExecutable uses the info files emitted by the CMake integration
 // in the configure step to look up the canonical path of a fuzz test's
 // executable.
@@ -264,7 +390,7 @@ func (b *Builder) getRuntimeDeps(fuzzTest string) ([]string, error) {
 		"cmake",
 		"--install",
 		b.BuildDir(),
-		"--config", cmakeBuildConfiguration,
+		"--config", b.config(),
 		"--component", "cifuzz_internal_deps_"+fuzzTest,
 	)
 	stdout, err := cmd.Output()
@@ -356,13 +482,16 @@ func (b *Builder) readInfoFileAsPath(fuzzTest string, kind string) (string, erro
 }
 
 func (b *Builder) fuzzTestsInfoDir() (string, error) {
-	// The path to the info file for single-configuration CMake generators (e.g. Makefiles).
+	// The path to the info file for single-configuration CMake generators
+	// (e.g. Makefiles, single-config Ninja).
 	fuzzTestsDir := filepath.Join(b.BuildDir(), ".cifuzz", "fuzz_tests")
 	if fileutil.IsDir(fuzzTestsDir) {
 		return fuzzTestsDir, nil
 	}
-	// The path to the info file for multi-configuration CMake generators (e.g. MSBuild).
-	fuzzTestsDir = filepath.Join(b.BuildDir(), cmakeBuildConfiguration, ".cifuzz", "fuzz_tests")
+	// The path to the info file for multi-configuration CMake generators
+	// (MSBuild, Ninja Multi-Config, Xcode): each configuration gets its
+	// own subdirectory named after it.
+	fuzzTestsDir = filepath.Join(b.BuildDir(), b.config(), ".cifuzz", "fuzz_tests")
 	if fileutil.IsDir(fuzzTestsDir) {
 		return fuzzTestsDir, nil
 	}
@@ -0,0 +1,35 @@
+package cmake
+
+import (
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+// engineCompiler returns the CIFUZZ_ENGINE_COMPILER cache variable's
+// value for engine: the instrumenting compiler wrapper a project's
+// CMakeLists.txt should invoke instead of the toolchain's plain C++
+// compiler. Empty means "use the default", which is correct for
+// libFuzzer - it's a Clang built-in sanitizer, not a separate wrapper.
+func engineCompiler(engine string) string {
+	switch engine {
+	case config.EngineHonggfuzz:
+		return "hfuzz-clang++"
+	case config.EngineAFLPlusPlus:
+		return "afl-clang-fast++"
+	default:
+		return ""
+	}
+}
+
+// engineLinkFlags returns the CIFUZZ_ENGINE_LINK_FLAGS cache variable's
+// value for engine: the extra linker flags needed to pull in that
+// engine's runtime archive via target_link_libraries. Empty means none
+// are needed beyond what engineCompiler's wrapper already adds itself,
+// which is the case for both libFuzzer and AFL++'s afl-clang-fast++.
+func engineLinkFlags(engine string) string {
+	switch engine {
+	case config.EngineHonggfuzz:
+		return "-lhfuzz"
+	default:
+		return ""
+	}
+}
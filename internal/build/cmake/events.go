@@ -0,0 +1,82 @@
+package cmake
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+
+	"code-intelligence.com/cifuzz/internal/build"
+)
+
+// targetProgressPattern matches the "[<done>/<total>] Building CXX
+// object ..." lines Make and Ninja both print per compile/link step.
+var targetProgressPattern = regexp.MustCompile(`^\[(\d+)/(\d+)\] (.+)$`)
+
+// diagnosticPattern matches a Clang/GCC-style compiler diagnostic line,
+// e.g. "src/foo.cc:12:5: error: use of undeclared identifier 'bar'".
+var diagnosticPattern = regexp.MustCompile(`^(.+):(\d+):(\d+): (error|warning): (.+)$`)
+
+// buildEventWriter tees cmake's build-phase stdout/stderr to dest
+// unchanged, while also parsing it line by line to emit TargetBuilt and
+// Diagnostic build.Events to events.
+type buildEventWriter struct {
+	dest   io.Writer
+	events io.Writer
+	buf    []byte
+}
+
+func (w *buildEventWriter) Write(data []byte) (int, error) {
+	n, err := w.dest.Write(data)
+	if err != nil {
+		return n, err
+	}
+
+	w.buf = append(w.buf, data...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx == -1 {
+			break
+		}
+		line := string(w.buf[:idx])
+		w.buf = w.buf[idx+1:]
+		w.parseLine(line)
+	}
+	return n, nil
+}
+
+func (w *buildEventWriter) parseLine(line string) {
+	if m := targetProgressPattern.FindStringSubmatch(line); m != nil {
+		done, _ := strconv.Atoi(m[1])
+		total, _ := strconv.Atoi(m[2])
+		_ = build.EmitEvent(w.events, build.Event{
+			Kind:   build.EventTargetBuilt,
+			Target: m[3],
+			Done:   done,
+			Total:  total,
+		})
+		return
+	}
+	if m := diagnosticPattern.FindStringSubmatch(line); m != nil {
+		diagnosticLine, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		_ = build.EmitEvent(w.events, build.Event{
+			Kind:     build.EventDiagnostic,
+			File:     m[1],
+			Line:     diagnosticLine,
+			Column:   column,
+			Severity: m[4],
+			Message:  m[5],
+		})
+	}
+}
+
+// buildOutput returns the io.Writer Build should point cmake's
+// stdout/stderr at: dest unchanged if no EventWriter is configured, or a
+// buildEventWriter that also parses it into structured events otherwise.
+func (b *Builder) buildOutput(dest io.Writer) io.Writer {
+	if b.EventWriter == nil {
+		return dest
+	}
+	return &buildEventWriter{dest: dest, events: b.EventWriter}
+}
@@ -0,0 +1,262 @@
+package cmake
+
+// This file implements Builder.AffectedFuzzTests, which uses the CMake
+// file-api (https://cmake.org/cmake/help/latest/manual/cmake-file-api.7.html)
+// codemodel-v2 query to find which fuzz tests a set of changed files
+// actually touches, so that "cifuzz run --only-affected" can skip
+// building and running the rest - the same idea as OSS-Fuzz's CIFuzz
+// project, implemented natively against CMake instead of as a separate
+// wrapper script.
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+func fileAPIQueryDir(buildDir string) string {
+	return filepath.Join(buildDir, ".cmake", "api", "v1", "query")
+}
+
+func fileAPIReplyDir(buildDir string) string {
+	return filepath.Join(buildDir, ".cmake", "api", "v1", "reply")
+}
+
+// writeFileAPIQuery drops an empty "codemodel-v2" query file in
+// buildDir's file-api query directory, so that the next time Configure
+// runs CMake, it writes the codemodel-v2 reply AffectedFuzzTests parses.
+func writeFileAPIQuery(buildDir string) error {
+	queryDir := fileAPIQueryDir(buildDir)
+	err := os.MkdirAll(queryDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(filepath.Join(queryDir, "codemodel-v2"), nil, 0644))
+}
+
+// fileAPIIndex is the top-level index-*.json file CMake writes to the
+// reply directory, pointing at the actual reply file for each requested
+// object kind.
+type fileAPIIndex struct {
+	Reply map[string]struct {
+		JSONFile string `json:"jsonFile"`
+	} `json:"reply"`
+}
+
+// codemodelReply is the codemodel-v2 reply file's relevant subset.
+type codemodelReply struct {
+	Paths struct {
+		Source string `json:"source"`
+	} `json:"paths"`
+	Configurations []struct {
+		Targets []struct {
+			Name     string `json:"name"`
+			ID       string `json:"id"`
+			JSONFile string `json:"jsonFile"`
+		} `json:"targets"`
+	} `json:"configurations"`
+}
+
+// codemodelTarget is the per-target reply file the codemodel-v2 reply
+// points at, one per target in the project.
+type codemodelTarget struct {
+	Sources []struct {
+		Path string `json:"path"`
+	} `json:"sources"`
+	Dependencies []struct {
+		ID string `json:"id"`
+	} `json:"dependencies"`
+}
+
+// latestFileAPIIndex returns the most recently written index-*.json file
+// in buildDir's file-api reply directory. CMake may leave stale ones
+// around from before the codemodel-v2 query file existed, so the newest
+// one is always the relevant one.
+func latestFileAPIIndex(buildDir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(fileAPIReplyDir(buildDir), "index-*.json"))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(matches) == 0 {
+		return "", errors.Errorf(
+			"no CMake file-api reply found in %s; run Configure first", fileAPIReplyDir(buildDir))
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// targetGraph maps each target's name to the absolute paths of the
+// source and header files it was built from, plus - transitively,
+// walked through its target_link_libraries dependencies in the
+// codemodel graph - the source files of every target it depends on. A
+// fuzz test is considered affected by a change to any file in its own
+// entry.
+func (b *Builder) targetGraph() (map[string][]string, error) {
+	replyDir := fileAPIReplyDir(b.BuildDir())
+
+	indexPath, err := latestFileAPIIndex(b.BuildDir())
+	if err != nil {
+		return nil, err
+	}
+	indexContent, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var index fileAPIIndex
+	err = json.Unmarshal(indexContent, &index)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	codemodelEntry, ok := index.Reply["codemodel-v2"]
+	if !ok {
+		return nil, errors.Errorf(
+			"CMake file-api reply %s doesn't contain a codemodel-v2 object; "+
+				"was the query file dropped before the last Configure run?", indexPath)
+	}
+
+	codemodelContent, err := os.ReadFile(filepath.Join(replyDir, codemodelEntry.JSONFile))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var codemodel codemodelReply
+	err = json.Unmarshal(codemodelContent, &codemodel)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(codemodel.Configurations) == 0 {
+		return nil, errors.Errorf("CMake file-api codemodel reply %s has no configurations", codemodelEntry.JSONFile)
+	}
+
+	idToName := make(map[string]string)
+	sourcesByName := make(map[string][]string)
+	depsByName := make(map[string][]string)
+	for _, target := range codemodel.Configurations[0].Targets {
+		idToName[target.ID] = target.Name
+
+		targetContent, err := os.ReadFile(filepath.Join(replyDir, target.JSONFile))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var targetInfo codemodelTarget
+		err = json.Unmarshal(targetContent, &targetInfo)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, source := range targetInfo.Sources {
+			path, err := fileutil.CanonicalPath(filepath.Join(codemodel.Paths.Source, source.Path))
+			if err != nil {
+				return nil, err
+			}
+			sourcesByName[target.Name] = append(sourcesByName[target.Name], path)
+		}
+		for _, dep := range targetInfo.Dependencies {
+			depsByName[target.Name] = append(depsByName[target.Name], dep.ID)
+		}
+	}
+
+	graph := make(map[string][]string, len(sourcesByName))
+	for name := range sourcesByName {
+		graph[name] = transitiveSources(name, sourcesByName, depsByName, idToName, map[string]bool{})
+	}
+	return graph, nil
+}
+
+// transitiveSources collects name's own sources plus, recursively, the
+// sources of every target it depends on. seen guards against revisiting
+// a target reachable through more than one dependency path.
+func transitiveSources(
+	name string,
+	sourcesByName map[string][]string,
+	depsByName map[string][]string,
+	idToName map[string]string,
+	seen map[string]bool,
+) []string {
+	if seen[name] {
+		return nil
+	}
+	seen[name] = true
+
+	sources := append([]string{}, sourcesByName[name]...)
+	for _, depID := range depsByName[name] {
+		depName, ok := idToName[depID]
+		if !ok {
+			continue
+		}
+		sources = append(sources, transitiveSources(depName, sourcesByName, depsByName, idToName, seen)...)
+	}
+	return sources
+}
+
+// changedFiles returns the absolute paths of the files that differ
+// between baseRef and the working tree, via "git diff --name-only".
+func (b *Builder) changedFiles(baseRef string) (map[string]bool, error) {
+	cmd := exec.Command("git", "diff", "--name-only", baseRef)
+	cmd.Dir = b.ProjectDir
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		err = cmdutils.WrapExecError(err, cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	changed := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		// A deleted file can't be a build input CMake still knows about,
+		// so canonicalizing it failing is not an error here.
+		path, err := fileutil.CanonicalPath(filepath.Join(b.ProjectDir, line))
+		if err != nil {
+			continue
+		}
+		changed[path] = true
+	}
+	return changed, nil
+}
+
+// AffectedFuzzTests returns the fuzz tests whose transitive source and
+// header inputs - including target_link_libraries dependencies walked
+// through the file-api's codemodel graph - were touched by the changes
+// between baseRef and the working tree.
+//
+// Configure must have run at least once after it started dropping the
+// codemodel-v2 query file, so that CMake has written the reply this
+// method parses.
+func (b *Builder) AffectedFuzzTests(baseRef string) ([]string, error) {
+	fuzzTests, err := b.ListFuzzTests()
+	if err != nil {
+		return nil, err
+	}
+	graph, err := b.targetGraph()
+	if err != nil {
+		return nil, err
+	}
+	changed, err := b.changedFiles(baseRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []string
+	for _, fuzzTest := range fuzzTests {
+		for _, source := range graph[fuzzTest] {
+			if changed[source] {
+				affected = append(affected, fuzzTest)
+				break
+			}
+		}
+	}
+	return affected, nil
+}
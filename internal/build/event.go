@@ -0,0 +1,63 @@
+package build
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// EventKind tags which of Event's fields are populated. A Builder writes
+// a stream of these as newline-delimited JSON to BuilderOptions'
+// EventWriter, letting IDE integrations (the VSCode/JetBrains plugins)
+// render progress bars and clickable diagnostics instead of scraping its
+// stdout/stderr text.
+type EventKind string
+
+const (
+	EventConfigureStart      EventKind = "ConfigureStart"
+	EventDiagnostic          EventKind = "Diagnostic"
+	EventTargetBuilt         EventKind = "TargetBuilt"
+	EventBuildFailed         EventKind = "BuildFailed"
+	EventRuntimeDepsResolved EventKind = "RuntimeDepsResolved"
+)
+
+// Event is one build-progress event. Which fields beyond Kind are set
+// depends on Kind; see the individual EventKind constants.
+type Event struct {
+	Kind EventKind `json:"kind"`
+
+	// File/Line/Column/Severity/Message are set for EventDiagnostic.
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message,omitempty"`
+
+	// Target/Done/Total are set for EventTargetBuilt: the
+	// "[<Done>/<Total>] Building <Target>" progress CMake's underlying
+	// build tool prints per compiled object or link step.
+	Target string `json:"target,omitempty"`
+	Done   int    `json:"done,omitempty"`
+	Total  int    `json:"total,omitempty"`
+
+	// Error is set for EventBuildFailed.
+	Error string `json:"error,omitempty"`
+
+	// FuzzTest/RuntimeDeps are set for EventRuntimeDepsResolved.
+	FuzzTest    string   `json:"fuzzTest,omitempty"`
+	RuntimeDeps []string `json:"runtimeDeps,omitempty"`
+}
+
+// EmitEvent writes event to w as one line of newline-delimited JSON. w
+// being nil (the default when BuilderOptions didn't set an EventWriter)
+// makes EmitEvent a no-op, so call sites don't need to guard every call.
+func EmitEvent(w io.Writer, event Event) error {
+	if w == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(encoded, '\n'))
+	return err
+}
@@ -0,0 +1,331 @@
+// Package golibfuzzer builds a libFuzzer-instrumented binary from a Go
+// fuzz function using the Go toolchain's "-tags=libfuzzer" support,
+// instead of Go's native `go test -fuzz` (see internal/build/gotest).
+// The result is a build.Result runnable by the same
+// pkg/runner/libfuzzer.Runner the C/C++ builders use, without any
+// changes to that runner.
+package golibfuzzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// libFuzzerMainC is linked against the c-archive Build produces, since
+// "-buildmode=c-archive" doesn't itself produce a "main".
+// LLVMFuzzerTestOneInput is exported by the Go archive; LLVMFuzzerRunDriver
+// comes from the libFuzzer runtime linked in via "-fsanitize=fuzzer".
+const libFuzzerMainC = `// Code generated by cifuzz; DO NOT EDIT.
+#include <stddef.h>
+#include <stdint.h>
+
+extern int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size);
+extern int LLVMFuzzerRunDriver(int *argc, char ***argv,
+                                int (*UserCb)(const uint8_t *data, size_t size));
+
+int main(int argc, char **argv) {
+  return LLVMFuzzerRunDriver(&argc, &argv, LLVMFuzzerTestOneInput);
+}
+`
+
+type ParallelOptions struct {
+	Enabled bool
+	NumJobs uint
+}
+
+type BuilderOptions struct {
+	ProjectDir string
+	Parallel   ParallelOptions
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	_, err := os.Stat(filepath.Join(opts.ProjectDir, "go.mod"))
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "ProjectDir does not contain a go.mod")
+	}
+	return nil
+}
+
+type Builder struct {
+	*BuilderOptions
+
+	// coverage builds the target with "-covermode=atomic" on top of
+	// libFuzzer's instrumentation, so that replaying the corpus produces
+	// Go coverage data instead of just running the fuzz target. Set via
+	// CoverageBuild.
+	coverage bool
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{BuilderOptions: opts}, nil
+}
+
+// Build compiles fuzzFunc, declared in the package at pkgPath (relative
+// to ProjectDir), with the Go toolchain's libFuzzer support, links it
+// against the libFuzzer driver, and returns the resulting build.Result.
+func (b *Builder) Build(pkgPath, fuzzFunc string) (*build.Result, error) {
+	err := validateFuzzFunc(filepath.Join(b.ProjectDir, pkgPath), fuzzFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	buildDir, err := os.MkdirTemp("", "cifuzz-go-libfuzzer-*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	archivePath := filepath.Join(buildDir, fuzzFunc+".a")
+	args := []string{
+		"build",
+		"-tags=libfuzzer",
+		"-gcflags=all=-d=libfuzzer",
+		"-buildmode=c-archive",
+		"-o", archivePath,
+	}
+	if b.coverage {
+		args = append(args, "-cover", "-covermode=atomic")
+	}
+	if b.Parallel.Enabled && b.Parallel.NumJobs != 0 {
+		args = append(args, "-p", fmt.Sprint(b.Parallel.NumJobs))
+	}
+	args = append(args, "./"+pkgPath)
+
+	err = b.runGo(args)
+	if err != nil {
+		return nil, err
+	}
+
+	mainPath := filepath.Join(buildDir, "main.c")
+	err = os.WriteFile(mainPath, []byte(libFuzzerMainC), 0644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	executable := filepath.Join(buildDir, fuzzFunc)
+	cmd := exec.Command("clang", mainPath, archivePath, "-fsanitize=fuzzer", "-o", executable)
+	cmd.Stdout = b.Stderr
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	importPath, err := b.importPath(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeDeps, err := b.runtimeDeps(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := importPath + "." + fuzzFunc
+	return &build.Result{
+		Name:       name,
+		Executable: executable,
+		BuildDir:   buildDir,
+		ProjectDir: b.ProjectDir,
+		// Unlike the Jazzer/Python builders, Go keeps a fuzz target's
+		// permanent seed corpus alongside its source, under
+		// testdata/fuzz/<FuzzFunc> - the same convention gotest.Builder
+		// uses for `go test -fuzz`, since this is the same corpus, just
+		// replayed through a libFuzzer-compatible binary instead.
+		SeedCorpus:      filepath.Join(b.ProjectDir, pkgPath, "testdata", "fuzz", fuzzFunc),
+		GeneratedCorpus: cmdutils.JazzerGeneratedCorpus(name, b.ProjectDir),
+		RuntimeDeps:     runtimeDeps,
+		Engine:          "libfuzzer",
+	}, nil
+}
+
+// CoverageBuild builds fuzzFunc the same way Build does, but with Go's
+// "-cover -covermode=atomic" instrumentation added on top of libFuzzer's.
+// It then replays the result's seed corpus once with GOCOVERDIR set,
+// and converts the coverage data Go writes there into "coverage.lcov"
+// under ProjectDir via "go tool covdata textfmt", mirroring how CMake's
+// "profile.lcov" is produced via llvm-profdata/llvm-cov.
+func (b *Builder) CoverageBuild(pkgPath, fuzzFunc string) (*build.Result, error) {
+	b.coverage = true
+	defer func() { b.coverage = false }()
+	result, err := b.Build(pkgPath, fuzzFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	coverDir, err := os.MkdirTemp("", "cifuzz-go-coverdir-*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	replayArgs := []string{"-runs=0"}
+	if _, err := os.Stat(result.SeedCorpus); err == nil {
+		replayArgs = append(replayArgs, result.SeedCorpus)
+	}
+	replayCmd := exec.Command(result.Executable, replayArgs...)
+	replayCmd.Dir = b.ProjectDir
+	replayCmd.Env = append(os.Environ(), "GOCOVERDIR="+coverDir)
+	replayCmd.Stdout = b.Stderr
+	replayCmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", replayCmd.String())
+	err = replayCmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), replayCmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	lcovFile := filepath.Join(b.ProjectDir, "coverage.lcov")
+	textCmd := exec.Command("go", "tool", "covdata", "textfmt", "-i="+coverDir, "-o="+lcovFile)
+	textCmd.Stdout = b.Stderr
+	textCmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", textCmd.String())
+	err = textCmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), textCmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	return result, nil
+}
+
+func (b *Builder) runGo(args []string) error {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stderr
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err := cmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+	return nil
+}
+
+func (b *Builder) importPath(pkgPath string) (string, error) {
+	cmd := exec.Command("go", "list", "./"+pkgPath)
+	cmd.Dir = b.ProjectDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// goListPackage is the subset of `go list -json`'s per-package object
+// this builder needs to resolve runtime dependencies.
+type goListPackage struct {
+	Dir      string `json:"Dir"`
+	Standard bool   `json:"Standard"`
+}
+
+// runtimeDeps resolves the non-standard-library packages pkgPath
+// transitively depends on via "go list -deps -json", mirroring how
+// cmake.Builder.getRuntimeDeps resolves shared library dependencies for
+// C/C++ fuzz targets.
+func (b *Builder) runtimeDeps(pkgPath string) ([]string, error) {
+	cmd := exec.Command("go", "list", "-deps", "-json", "./"+pkgPath)
+	cmd.Dir = b.ProjectDir
+	out, err := cmd.Output()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	var deps []string
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for decoder.More() {
+		var pkg goListPackage
+		err := decoder.Decode(&pkg)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if pkg.Standard || pkg.Dir == "" {
+			continue
+		}
+		deps = append(deps, pkg.Dir)
+	}
+
+	return deps, nil
+}
+
+// validateFuzzFunc parses pkgDir's Go files and checks that fuzzFunc is
+// declared with the "func FuzzXxx(f *testing.F)" signature `go test
+// -fuzz` targets use, so a malformed fuzz function fails with a clear
+// error here instead of a confusing one from the compiler later.
+func validateFuzzFunc(pkgDir, fuzzFunc string) error {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, pkgDir, nil, 0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Name.Name != fuzzFunc {
+					continue
+				}
+				if isFuzzFuncSignature(fn) {
+					return nil
+				}
+				return errors.Errorf(
+					"%s is not a valid fuzz function: expected signature func %s(f *testing.F)",
+					fuzzFunc, fuzzFunc)
+			}
+		}
+	}
+
+	return errors.Errorf("fuzz function %s not found in %s", fuzzFunc, pkgDir)
+}
+
+func isFuzzFuncSignature(fn *ast.FuncDecl) bool {
+	if fn.Type.Params == nil || len(fn.Type.Params.List) != 1 {
+		return false
+	}
+	param := fn.Type.Params.List[0]
+	star, ok := param.Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkgIdent, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	return pkgIdent.Name == "testing" && sel.Sel.Name == "F"
+}
@@ -0,0 +1,49 @@
+package golibfuzzer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundleGo verifies the wrapped binary, the testdata/fuzz seed
+// corpus, and a clean "-runs=0" execution, the same way the OSS-Fuzz
+// bundler would drive any other LLVMFuzzerTestOneInput-based target.
+// It requires clang, which isn't available everywhere this package is
+// built, so it skips itself if clang can't be found.
+func TestBundleGo(t *testing.T) {
+	if _, err := exec.LookPath("clang"); err != nil {
+		t.Skip("clang not available")
+	}
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module example.com/fuzztest\n\ngo 1.18\n"), 0o644))
+	fuzzSrc := `package fuzztest
+
+import "testing"
+
+func FuzzFoo(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {})
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "fuzz_test.go"), []byte(fuzzSrc), 0o644))
+
+	seedCorpusDir := filepath.Join(projectDir, "testdata", "fuzz", "FuzzFoo")
+	require.NoError(t, os.MkdirAll(seedCorpusDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpusDir, "seed1"), []byte("go test fuzz v1\n[]byte(\"a\")\n"), 0o644))
+
+	b, err := NewBuilder(&BuilderOptions{ProjectDir: projectDir})
+	require.NoError(t, err)
+
+	result, err := b.Build(".", "FuzzFoo")
+	require.NoError(t, err)
+	require.Equal(t, "libfuzzer", result.Engine)
+	require.Equal(t, seedCorpusDir, result.SeedCorpus)
+	require.FileExists(t, result.Executable)
+
+	cmd := exec.Command(result.Executable, "-runs=0")
+	require.NoError(t, cmd.Run())
+}
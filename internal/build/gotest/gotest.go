@@ -0,0 +1,105 @@
+// Package gotest locates Go native `testing.F` fuzz targets (run via `go
+// test -fuzz`, as implemented by pkg/runner/gonative) the same way the
+// cmake and maven packages locate C/C++ and JVM fuzz targets.
+package gotest
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type BuilderOptions struct {
+	ProjectDir string
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	_, err := os.Stat(filepath.Join(opts.ProjectDir, "go.mod"))
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "ProjectDir does not contain a go.mod")
+	}
+	return nil
+}
+
+type Builder struct {
+	*BuilderOptions
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{BuilderOptions: opts}, nil
+}
+
+// Build verifies that fuzzTest's package compiles and resolves the corpus
+// directories pkg/runner/gonative needs. Unlike the other builders, there's
+// no separate compile step to run ahead of time: `go test -fuzz` builds and
+// runs the fuzz target itself, so Build's only jobs are failing fast if the
+// package doesn't compile and resolving paths.
+func (b *Builder) Build(fuzzTest string) (*build.Result, error) {
+	cmd := exec.Command("go", "vet", ".")
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stderr
+	cmd.Stderr = b.Stderr
+	err := cmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	importPath, err := b.importPath()
+	if err != nil {
+		return nil, err
+	}
+
+	gocache, err := goEnv("GOCACHE")
+	if err != nil {
+		return nil, err
+	}
+
+	return &build.Result{
+		Name:       fuzzTest,
+		ProjectDir: b.ProjectDir,
+		// Go keeps the permanent seed corpus alongside the test source...
+		SeedCorpus: filepath.Join(b.ProjectDir, "testdata", "fuzz", fuzzTest),
+		// ...and the working corpus generated during a run under GOCACHE.
+		GeneratedCorpus: filepath.Join(gocache, "fuzz", importPath, fuzzTest),
+	}, nil
+}
+
+// importPath resolves the import path of the package in ProjectDir, which
+// pkg/runner/gonative's GeneratedCorpusDir is keyed by.
+func (b *Builder) importPath() (string, error) {
+	cmd := exec.Command("go", "list", ".")
+	cmd.Dir = b.ProjectDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func goEnv(key string) (string, error) {
+	cmd := exec.Command("go", "env", key)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,51 @@
+package build
+
+import (
+	"context"
+	"io"
+)
+
+// FuzzTarget is the per-target result a Build's Fuzzer method returns.
+// It is an alias of Result, not a distinct type, so a *FuzzTarget can be
+// returned wherever the existing exported Build/BuildForRun methods
+// return a *Result, and vice versa.
+type FuzzTarget = Result
+
+// Build is implemented by each build system integration (currently
+// internal/build/maven and internal/build/python; internal/build/cmake,
+// internal/build/bazel and the others are expected to grow the same
+// methods over time) so that callers which only need to discover and
+// build fuzz targets - cmd/run already does, for Maven and Python - can
+// be written once against Build instead of once per build system, and a
+// future backend (Bazel, SBT, ...) can be added without touching those
+// call sites.
+//
+// Prepare must be called once, before ListFuzzers, Fuzzer or Path are
+// used; implementations memoize the (often expensive) work it does, so
+// calling it again is cheap.
+type Build interface {
+	// Prepare performs whatever discovery and compilation steps the
+	// build system needs to build every fuzz target in the project -
+	// for Maven, "test-compile" and "dependency:build-classpath" - and
+	// memoizes the result.
+	Prepare(ctx context.Context) error
+
+	// Fuzzer returns the build result for the named fuzz target. It
+	// requires a prior call to Prepare.
+	Fuzzer(name string) (*FuzzTarget, error)
+
+	// ListFuzzers returns the names of every fuzz target Prepare found
+	// in the project.
+	ListFuzzers() ([]string, error)
+
+	// Path resolves one or more well-known, build-system-specific paths,
+	// e.g. Path("build-dir"), Path("runtime-deps"), or
+	// Path("seed-corpus", target), replacing the ad-hoc getters each
+	// builder used to expose individually.
+	Path(keys ...string) ([]string, error)
+
+	// Symbolize streams fuzzer output read from in to out, resolving
+	// any unsymbolized frames it recognizes along the way, and closes
+	// in once done.
+	Symbolize(in io.ReadCloser, out io.Writer) error
+}
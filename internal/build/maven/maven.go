@@ -2,11 +2,14 @@ package maven
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -14,6 +17,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runner"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
@@ -44,6 +48,18 @@ func (opts *BuilderOptions) Validate() error {
 
 type Builder struct {
 	*BuilderOptions
+
+	// coverage activates the "cifuzz-coverage" Maven profile in addition
+	// to "cifuzz", which attaches Jazzer's JaCoCo coverage agent to the
+	// fuzzing run. Set via CoverageBuild.
+	coverage bool
+
+	// prepared is set once Prepare has run test-compile and
+	// dependency:build-classpath, so that later Prepare, Fuzzer and Path
+	// calls on the same Builder don't repeat that work.
+	prepared bool
+	buildDir string
+	deps     []string
 }
 
 func NewBuilder(opts *BuilderOptions) (*Builder, error) {
@@ -57,7 +73,15 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 	return b, err
 }
 
-func (b *Builder) Build(targetClass string) (*build.Result, error) {
+// Prepare implements build.Build. It runs "test-compile" and
+// "dependency:build-classpath" once for the whole project and memoizes
+// their results, so that Fuzzer and Path calls for individual targets
+// don't re-run Maven.
+func (b *Builder) Prepare(ctx context.Context) error {
+	if b.prepared {
+		return nil
+	}
+
 	var flags []string
 	if b.Parallel.Enabled {
 		flags = append(flags, "-T")
@@ -72,37 +96,154 @@ func (b *Builder) Build(targetClass string) (*build.Result, error) {
 
 	err := b.runMaven(args, b.Stderr, b.Stderr)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	deps, err := b.getExternalDependencies()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	localDeps, err := b.getLocalDependencies()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	b.deps = append(deps, localDeps...)
 
-	deps = append(deps, localDeps...)
-
-	seedCorpus := cmdutils.JazzerSeedCorpus(targetClass, b.ProjectDir)
-	generatedCorpus := cmdutils.JazzerGeneratedCorpus(targetClass, b.ProjectDir)
-	buildDir, err := GetBuildDirectory(b.ProjectDir)
+	b.buildDir, err = GetBuildDirectory(b.ProjectDir)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	result := &build.Result{
+
+	b.prepared = true
+	return nil
+}
+
+// Fuzzer implements build.Build, returning the build result for
+// targetClass from the state Prepare recorded.
+func (b *Builder) Fuzzer(targetClass string) (*build.FuzzTarget, error) {
+	if !b.prepared {
+		return nil, errors.New("Prepare must be called before Fuzzer")
+	}
+	return &build.FuzzTarget{
 		Name:            targetClass,
-		BuildDir:        buildDir,
+		BuildDir:        b.buildDir,
 		ProjectDir:      b.ProjectDir,
-		GeneratedCorpus: generatedCorpus,
-		SeedCorpus:      seedCorpus,
-		RuntimeDeps:     deps,
+		GeneratedCorpus: cmdutils.JazzerGeneratedCorpus(targetClass, b.ProjectDir),
+		SeedCorpus:      cmdutils.JazzerSeedCorpus(targetClass, b.ProjectDir),
+		RuntimeDeps:     b.deps,
+	}, nil
+}
+
+// fuzzerTestOneInputDecl matches a Jazzer fuzz test method declaration,
+// e.g. "public static void fuzzerTestOneInput(FuzzedDataProvider data)".
+var fuzzerTestOneInputDecl = regexp.MustCompile(`\bvoid\s+fuzzerTestOneInput\s*\(`)
+
+var javaPackageDecl = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+
+// ListFuzzers implements build.Build by scanning the project's test
+// sources for classes declaring a fuzzerTestOneInput method, the method
+// Jazzer looks for when running a fuzz test.
+func (b *Builder) ListFuzzers() ([]string, error) {
+	if !b.prepared {
+		return nil, errors.New("Prepare must be called before ListFuzzers")
 	}
 
-	return result, nil
+	var fuzzers []string
+	testSrcDir := filepath.Join(b.ProjectDir, "src", "test", "java")
+	err := filepath.WalkDir(testSrcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".java") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !fuzzerTestOneInputDecl.Match(content) {
+			return nil
+		}
+
+		className := strings.TrimSuffix(filepath.Base(path), ".java")
+		if m := javaPackageDecl.FindSubmatch(content); m != nil {
+			className = string(m[1]) + "." + className
+		}
+		fuzzers = append(fuzzers, className)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return fuzzers, nil
+}
+
+// Path implements build.Build, replacing the previous ad-hoc getters
+// (GetBuildDirectory, the Build result's RuntimeDeps/SeedCorpus fields)
+// with a single lookup keyed by name. Supported keys are "build-dir",
+// "runtime-deps", "seed-corpus" and "generated-corpus"; the latter two
+// take the fuzz target's class name as a second argument.
+func (b *Builder) Path(keys ...string) ([]string, error) {
+	if !b.prepared {
+		return nil, errors.New("Prepare must be called before Path")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("Path requires at least one key")
+	}
+
+	switch keys[0] {
+	case "build-dir":
+		return []string{b.buildDir}, nil
+	case "runtime-deps":
+		return b.deps, nil
+	case "seed-corpus":
+		if len(keys) != 2 {
+			return nil, errors.New(`Path("seed-corpus", target) requires a target name`)
+		}
+		return []string{cmdutils.JazzerSeedCorpus(keys[1], b.ProjectDir)}, nil
+	case "generated-corpus":
+		if len(keys) != 2 {
+			return nil, errors.New(`Path("generated-corpus", target) requires a target name`)
+		}
+		return []string{cmdutils.JazzerGeneratedCorpus(keys[1], b.ProjectDir)}, nil
+	default:
+		return nil, errors.Errorf("unknown path key %q", keys[0])
+	}
+}
+
+// Symbolize implements build.Build by streaming fuzzer output through a
+// runner.Symbolizer, so a Jazzer crash log can still be resolved on a
+// machine other than the one that built the target.
+func (b *Builder) Symbolize(in io.ReadCloser, out io.Writer) error {
+	defer in.Close()
+	symbolizer := &runner.Symbolizer{}
+	return symbolizer.Symbolize(in, out)
+}
+
+// Build implements the original, single-target entry point in terms of
+// Prepare and Fuzzer, kept for callers (cmd/coverage, via CoverageBuild) that
+// haven't moved to the build.Build interface yet; cmd/run now calls
+// Prepare and Fuzzer directly.
+func (b *Builder) Build(targetClass string) (*build.Result, error) {
+	err := b.Prepare(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return b.Fuzzer(targetClass)
+}
+
+// CoverageBuild builds targetClass the same way Build does, but attaches
+// Jazzer's JaCoCo coverage agent to the fuzzing run via the
+// "cifuzz-coverage" Maven profile, so that replaying the corpus through
+// the target produces JaCoCo coverage data instead of just running the
+// fuzz test.
+func (b *Builder) CoverageBuild(targetClass string) (*build.Result, error) {
+	b.coverage = true
+	defer func() { b.coverage = false }()
+	return b.Build(targetClass)
 }
 
 func (b *Builder) getExternalDependencies() ([]string, error) {
@@ -171,6 +312,9 @@ func (b *Builder) getLocalDependencies() ([]string, error) {
 func (b *Builder) runMaven(args []string, stdout, stderr io.Writer) error {
 	// always run it with the cifuzz profile
 	args = append(args, "-Pcifuzz")
+	if b.coverage {
+		args = append(args, "-Pcifuzz-coverage")
+	}
 	cmd := exec.Command(
 		"mvn",
 		args...,
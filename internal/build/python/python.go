@@ -0,0 +1,313 @@
+// Package python builds Python fuzz targets driven by atheris, the way
+// internal/build/maven builds Jazzer ones and internal/build/golibfuzzer
+// builds Go ones.
+package python
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type BuilderOptions struct {
+	ProjectDir string
+	// TempDir is the directory generated wrapper scripts are written to,
+	// the same role bazel.BuilderOptions.TempDir plays for bazel's
+	// generated "bazel run --script_path" wrappers.
+	TempDir string
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+type Builder struct {
+	*BuilderOptions
+
+	// prepared is set once Prepare has installed atheris (and the
+	// project's own requirements, if any) into depsDir, so that later
+	// Prepare, Fuzzer and Path calls don't repeat that work.
+	prepared bool
+	depsDir  string
+	deps     []string
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{BuilderOptions: opts}, nil
+}
+
+// Prepare implements build.Build. It installs atheris, and the project's
+// requirements.txt if it has one, into a dedicated directory via "pip
+// install --target", the frozen-wheels approach the bundler's
+// runtime_deps/ layout expects rather than a full virtualenv.
+func (b *Builder) Prepare(ctx context.Context) error {
+	if b.prepared {
+		return nil
+	}
+
+	depsDir, err := os.MkdirTemp("", "cifuzz-python-deps-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	b.depsDir = depsDir
+
+	args := []string{"install", "--target", depsDir, "atheris"}
+	requirementsTxt := filepath.Join(b.ProjectDir, "requirements.txt")
+	if exists, err := exists(requirementsTxt); err != nil {
+		return err
+	} else if exists {
+		args = append(args, "-r", requirementsTxt)
+	}
+
+	cmd := exec.CommandContext(ctx, "pip", args...)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+
+	b.deps = []string{depsDir}
+	b.prepared = true
+	return nil
+}
+
+// testOneInputDecl matches an atheris fuzz target's entry point, e.g.
+// "def TestOneInput(data):".
+var testOneInputDecl = regexp.MustCompile(`\bdef\s+TestOneInput\s*\(`)
+
+// ListFuzzers implements build.Build by scanning the project for .py
+// files that declare a TestOneInput function, the entry point atheris
+// fuzz targets are expected to expose.
+func (b *Builder) ListFuzzers() ([]string, error) {
+	if !b.prepared {
+		return nil, errors.New("Prepare must be called before ListFuzzers")
+	}
+
+	var fuzzers []string
+	err := filepath.WalkDir(b.ProjectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".py") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !testOneInputDecl.Match(content) {
+			return nil
+		}
+
+		fuzzers = append(fuzzers, b.moduleName(path))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return fuzzers, nil
+}
+
+// moduleName turns the path of a .py file under ProjectDir into the
+// dotted module name Python's import machinery would use for it.
+func (b *Builder) moduleName(path string) string {
+	rel, err := filepath.Rel(b.ProjectDir, path)
+	if err != nil {
+		return strings.TrimSuffix(filepath.Base(path), ".py")
+	}
+	rel = strings.TrimSuffix(rel, ".py")
+	return strings.ReplaceAll(rel, string(filepath.Separator), ".")
+}
+
+// Fuzzer implements build.Build, generating the wrapper script that
+// invokes atheris for the named fuzz target and returning the build
+// result for it.
+func (b *Builder) Fuzzer(name string) (*build.FuzzTarget, error) {
+	if !b.prepared {
+		return nil, errors.New("Prepare must be called before Fuzzer")
+	}
+
+	wrapperPath, err := b.writeWrapperScript(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &build.FuzzTarget{
+		Name:            name,
+		Executable:      wrapperPath,
+		BuildDir:        b.ProjectDir,
+		ProjectDir:      b.ProjectDir,
+		SeedCorpus:      cmdutils.JazzerSeedCorpus(name, b.ProjectDir),
+		GeneratedCorpus: cmdutils.JazzerGeneratedCorpus(name, b.ProjectDir),
+		RuntimeDeps:     b.deps,
+		Engine:          "libfuzzer",
+		Runtime:         "python",
+	}, nil
+}
+
+// wrapperScript is the template for the script Fuzzer generates for a
+// target: it sets up atheris the way the request's own
+// "atheris.Setup(sys.argv, target); atheris.Fuzz()" expects, and accepts
+// libFuzzer's CLI flags (e.g. -runs=0) directly, since atheris forwards
+// sys.argv to libFuzzer's driver unchanged.
+const wrapperScript = `#!/usr/bin/env python3
+import sys
+
+sys.path.insert(0, %q)
+sys.path.insert(0, %q)
+
+import atheris
+
+from %s import TestOneInput
+
+if __name__ == "__main__":
+    atheris.Setup(sys.argv, TestOneInput)
+    atheris.Fuzz()
+`
+
+func (b *Builder) writeWrapperScript(name string) (string, error) {
+	script := fmt.Sprintf(wrapperScript, b.depsDir, b.ProjectDir, name)
+
+	wrapperPath := filepath.Join(b.TempDir, name+"_wrapper.py")
+	err := os.WriteFile(wrapperPath, []byte(script), 0o755)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return wrapperPath, nil
+}
+
+// Path implements build.Build. Supported keys are "build-dir",
+// "runtime-deps", "seed-corpus" and "generated-corpus", mirroring
+// maven.Builder.Path.
+func (b *Builder) Path(keys ...string) ([]string, error) {
+	if !b.prepared {
+		return nil, errors.New("Prepare must be called before Path")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("Path requires at least one key")
+	}
+
+	switch keys[0] {
+	case "build-dir":
+		return []string{b.ProjectDir}, nil
+	case "runtime-deps":
+		return b.deps, nil
+	case "seed-corpus":
+		if len(keys) != 2 {
+			return nil, errors.New(`Path("seed-corpus", target) requires a target name`)
+		}
+		return []string{cmdutils.JazzerSeedCorpus(keys[1], b.ProjectDir)}, nil
+	case "generated-corpus":
+		if len(keys) != 2 {
+			return nil, errors.New(`Path("generated-corpus", target) requires a target name`)
+		}
+		return []string{cmdutils.JazzerGeneratedCorpus(keys[1], b.ProjectDir)}, nil
+	default:
+		return nil, errors.Errorf("unknown path key %q", keys[0])
+	}
+}
+
+// Symbolize implements build.Build. Python tracebacks already name their
+// source files and line numbers, so there are no unsymbolized frames to
+// resolve; output is passed through unchanged.
+func (b *Builder) Symbolize(in io.ReadCloser, out io.Writer) error {
+	defer in.Close()
+	_, err := io.Copy(out, in)
+	return errors.WithStack(err)
+}
+
+// Build implements the original, single-target entry point in terms of
+// Prepare and Fuzzer, kept for callers (cmd/coverage) that haven't
+// moved to the build.Build interface yet; cmd/run now calls Prepare and
+// Fuzzer directly.
+func (b *Builder) Build(name string) (*build.Result, error) {
+	err := b.Prepare(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return b.Fuzzer(name)
+}
+
+// CoverageBuild builds name the same way Build does, then replays its
+// seed corpus through coverage.py and writes the result out as
+// "coverage.lcov" in ProjectDir, analogously to how CMake's
+// "profile.lcov" is produced via llvm-profdata/llvm-cov.
+func (b *Builder) CoverageBuild(name string) (*build.Result, error) {
+	result, err := b.Build(name)
+	if err != nil {
+		return nil, err
+	}
+
+	coverageData := filepath.Join(b.ProjectDir, ".coverage")
+	runArgs := []string{"run", "--data-file=" + coverageData, result.Executable, result.SeedCorpus}
+	runCmd := exec.Command("coverage", runArgs...)
+	runCmd.Dir = b.ProjectDir
+	runCmd.Stdout = b.Stdout
+	runCmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", runCmd.String())
+	err = runCmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), runCmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	lcovFile := filepath.Join(b.ProjectDir, "coverage.lcov")
+	lcovArgs := []string{"lcov", "--data-file=" + coverageData, "-o", lcovFile}
+	lcovCmd := exec.Command("coverage", lcovArgs...)
+	lcovCmd.Dir = b.ProjectDir
+	lcovCmd.Stdout = b.Stdout
+	lcovCmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", lcovCmd.String())
+	err = lcovCmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), lcovCmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	return result, nil
+}
+
+func exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, errors.WithStack(err)
+}
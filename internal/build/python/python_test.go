@@ -0,0 +1,56 @@
+package python
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundlePython verifies the parts of the atheris bundling pipeline
+// that don't require atheris itself to be installed: fuzz target
+// discovery and the wrapper script Fuzzer generates for it.
+func TestBundlePython(t *testing.T) {
+	projectDir := t.TempDir()
+	fuzzTestPath := filepath.Join(projectDir, "my_fuzz_test.py")
+	fuzzTestSrc := "import atheris\n\n\ndef TestOneInput(data):\n    pass\n"
+	require.NoError(t, os.WriteFile(fuzzTestPath, []byte(fuzzTestSrc), 0o644))
+
+	b := &Builder{
+		BuilderOptions: &BuilderOptions{ProjectDir: projectDir, TempDir: t.TempDir()},
+		prepared:       true,
+		depsDir:        filepath.Join(projectDir, "runtime_deps"),
+		deps:           []string{filepath.Join(projectDir, "runtime_deps")},
+	}
+
+	fuzzers, err := b.ListFuzzers()
+	require.NoError(t, err)
+	require.Equal(t, []string{"my_fuzz_test"}, fuzzers)
+
+	result, err := b.Fuzzer("my_fuzz_test")
+	require.NoError(t, err)
+	require.Equal(t, "libfuzzer", result.Engine)
+	require.Equal(t, "python", result.Runtime)
+	require.Equal(t, b.deps, result.RuntimeDeps)
+
+	wrapper, err := os.ReadFile(result.Executable)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(wrapper), "#!/usr/bin/env python3\n"))
+	require.Contains(t, string(wrapper), "from my_fuzz_test import TestOneInput")
+	require.Contains(t, string(wrapper), "atheris.Setup(sys.argv, TestOneInput)")
+
+	info, err := os.Stat(result.Executable)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+	cmd := exec.Command("python3", result.Executable, "-runs=0")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("atheris not installed: %s", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package rust builds cargo-fuzz fuzz targets - the "fuzz_target!" macros
+// declared under a crate's "fuzz/fuzz_targets/" directory - the way
+// internal/build/golibfuzzer builds Go ones: the result is a build.Result
+// runnable by the same pkg/runner/libfuzzer.Runner the C/C++ builders use,
+// since cargo-fuzz targets are themselves libFuzzer binaries under the
+// hood.
+package rust
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// defaultSanitizer is cargo-fuzz's own default for "cargo fuzz build -s".
+const defaultSanitizer = "address"
+
+type BuilderOptions struct {
+	ProjectDir string
+	// Sanitizer is passed to cargo-fuzz as "-s <Sanitizer>"; it defaults
+	// to "address" if unset.
+	Sanitizer string
+	Stdout    io.Writer
+	Stderr    io.Writer
+}
+
+func (opts *BuilderOptions) Validate() error {
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	_, err := os.Stat(filepath.Join(opts.ProjectDir, "fuzz", "Cargo.toml"))
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "ProjectDir does not contain a fuzz/Cargo.toml")
+	}
+	return nil
+}
+
+type Builder struct {
+	*BuilderOptions
+}
+
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{BuilderOptions: opts}, nil
+}
+
+func (b *Builder) sanitizer() string {
+	if b.Sanitizer != "" {
+		return b.Sanitizer
+	}
+	return defaultSanitizer
+}
+
+func (b *Builder) fuzzDir() string {
+	return filepath.Join(b.ProjectDir, "fuzz")
+}
+
+// fuzzTargetMacro matches a cargo-fuzz target's "fuzz_target!(...)" macro
+// invocation, the entry point every file under fuzz/fuzz_targets/ is
+// expected to declare exactly one of.
+var fuzzTargetMacro = regexp.MustCompile(`\bfuzz_target!`)
+
+// ListFuzzers returns the names of every cargo-fuzz target declared under
+// fuzz/fuzz_targets/, derived from their file names the same way
+// cargo-fuzz itself does.
+func (b *Builder) ListFuzzers() ([]string, error) {
+	targetsDir := filepath.Join(b.fuzzDir(), "fuzz_targets")
+	var fuzzers []string
+	err := filepath.WalkDir(targetsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".rs") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !fuzzTargetMacro.Match(content) {
+			return nil
+		}
+
+		fuzzers = append(fuzzers, strings.TrimSuffix(filepath.Base(path), ".rs"))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return fuzzers, nil
+}
+
+// Build invokes cargo-fuzz to compile name, the same way a developer
+// running "cargo fuzz build" by hand would, and returns the resulting
+// build.Result.
+func (b *Builder) Build(name string) (*build.Result, error) {
+	cmd := exec.Command("cargo", "+nightly", "fuzz", "build", "--release", "-s", b.sanitizer(), name)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stderr
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err := cmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	executable, err := b.findExecutable(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &build.Result{
+		Name:            name,
+		Executable:      executable,
+		BuildDir:        filepath.Join(b.fuzzDir(), "target"),
+		ProjectDir:      b.ProjectDir,
+		SeedCorpus:      filepath.Join(b.fuzzDir(), "corpus", name),
+		GeneratedCorpus: cmdutils.JazzerGeneratedCorpus(name, b.ProjectDir),
+		Dictionary:      b.dictionary(name),
+		Engine:          "libfuzzer",
+		Runtime:         "rust",
+	}, nil
+}
+
+// dictionary returns the path of name's own dictionary, cargo-fuzz's
+// "fuzz/<target>.dict" convention, or "" if it doesn't have one.
+func (b *Builder) dictionary(name string) string {
+	path := filepath.Join(b.fuzzDir(), name+".dict")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// findExecutable locates the release binary cargo-fuzz just built for
+// name. It's written under a host-specific target-triple directory
+// (e.g. "x86_64-unknown-linux-gnu"), so the triple is resolved via a
+// glob instead of being hardcoded.
+func (b *Builder) findExecutable(name string) (string, error) {
+	pattern := filepath.Join(b.fuzzDir(), "target", "*", "release", name)
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(matches) == 0 {
+		return "", errors.Errorf("no fuzz target binary found matching %s", pattern)
+	}
+	return matches[0], nil
+}
+
+// CoverageBuild builds name via "cargo fuzz coverage" instead of "cargo
+// fuzz build": cargo-fuzz runs the target over its corpus itself and
+// writes the merged result to fuzz/coverage/<name>/coverage.profdata.
+// That's converted into "coverage.lcov" under ProjectDir via llvm-cov,
+// the same way CMake's CoverageBuild's raw profile is in cmd/coverage.
+func (b *Builder) CoverageBuild(name string) (*build.Result, error) {
+	result, err := b.Build(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("cargo", "+nightly", "fuzz", "coverage", "--release", "-s", b.sanitizer(), name)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stderr
+	cmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	profData := filepath.Join(b.fuzzDir(), "coverage", name, "coverage.profdata")
+	lcovFile := filepath.Join(b.ProjectDir, "coverage.lcov")
+	out, err := os.Create(lcovFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer out.Close()
+
+	showCmd := exec.Command("llvm-cov", "show", result.Executable, "-instr-profile="+profData, "-format=lcov")
+	showCmd.Stdout = out
+	showCmd.Stderr = b.Stderr
+	log.Debugf("Command: %s", showCmd.String())
+	err = showCmd.Run()
+	if err != nil {
+		err = cmdutils.WrapExecError(errors.WithStack(err), showCmd)
+		log.Error(err)
+		return nil, cmdutils.ErrSilent
+	}
+
+	return result, nil
+}
+
+// Symbolize implements the same passthrough python.Builder uses:
+// cargo-fuzz binaries are already built with
+// symbols embedded by default, so there are no unsymbolized frames to
+// resolve here.
+func (b *Builder) Symbolize(in io.ReadCloser, out io.Writer) error {
+	defer in.Close()
+	_, err := io.Copy(out, in)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,80 @@
+package rust
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBundleCargoFuzz verifies ListFuzzers, the wrapped binary, the
+// fuzz/corpus/<target> seed corpus, and a clean "-runs=0" execution, the
+// same way TestBundleLibFuzzer verifies a CMake target. It requires the
+// nightly cargo-fuzz toolchain, which isn't available everywhere this
+// package is built, so it skips itself if cargo can't be found.
+func TestBundleCargoFuzz(t *testing.T) {
+	if _, err := exec.LookPath("cargo"); err != nil {
+		t.Skip("cargo not available")
+	}
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Cargo.toml"), []byte("[package]\nname = \"fuzztest\"\nversion = \"0.1.0\"\nedition = \"2021\"\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "src", "lib.rs"), []byte("pub fn noop() {}\n"), 0o644))
+
+	fuzzDir := filepath.Join(projectDir, "fuzz")
+	require.NoError(t, os.MkdirAll(fuzzDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(fuzzDir, "Cargo.toml"), []byte(`[package]
+name = "fuzztest-fuzz"
+version = "0.0.0"
+edition = "2021"
+publish = false
+
+[package.metadata]
+cargo-fuzz = true
+
+[dependencies]
+libfuzzer-sys = "0.4"
+
+[[bin]]
+name = "fuzz_foo"
+path = "fuzz_targets/fuzz_foo.rs"
+test = false
+doc = false
+`), 0o644))
+
+	targetsDir := filepath.Join(fuzzDir, "fuzz_targets")
+	require.NoError(t, os.MkdirAll(targetsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(targetsDir, "fuzz_foo.rs"), []byte(`#![no_main]
+use libfuzzer_sys::fuzz_target;
+
+fuzz_target!(|data: &[u8]| {});
+`), 0o644))
+
+	corpusDir := filepath.Join(fuzzDir, "corpus", "fuzz_foo")
+	require.NoError(t, os.MkdirAll(corpusDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "seed1"), []byte("a"), 0o644))
+
+	b, err := NewBuilder(&BuilderOptions{ProjectDir: projectDir})
+	require.NoError(t, err)
+
+	fuzzers, err := b.ListFuzzers()
+	require.NoError(t, err)
+	require.Contains(t, fuzzers, "fuzz_foo")
+
+	result, err := b.Build("fuzz_foo")
+	require.NoError(t, err)
+	require.Equal(t, "libfuzzer", result.Engine)
+	require.Equal(t, "rust", result.Runtime)
+	require.Equal(t, corpusDir, result.SeedCorpus)
+	require.FileExists(t, result.Executable)
+
+	cmd := exec.Command(result.Executable, "-runs=0")
+	require.NoError(t, cmd.Run())
+
+	result, err = b.CoverageBuild("fuzz_foo")
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(projectDir, "coverage.lcov"))
+}
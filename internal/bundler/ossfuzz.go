@@ -0,0 +1,216 @@
+package bundler
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// OSSFuzzOptions configures WriteOSSFuzzBundle.
+type OSSFuzzOptions struct {
+	// Dictionary, if set, is copied alongside every fuzz target that
+	// doesn't have its own build.Result.Dictionary as "<target>.dict"
+	// and referenced from its ".options" file, the same dictionary
+	// every run of cifuzz itself would pass via -dict=.
+	Dictionary string
+	// CloseFDMask is written as the ".options" file's "close_fd_mask",
+	// which ClusterFuzz passes libFuzzer to redirect the target's
+	// stdout/stderr (1), or both (3), away from its own log parsing.
+	CloseFDMask int
+	// EngineArgs are written as the ".options" file's "[libfuzzer]"
+	// section's "detect_leaks"-style flags, one "<key> = <value>" line
+	// per "-key=value" argument; arguments that aren't of that form are
+	// skipped, since the OSS-Fuzz options format has no equivalent of a
+	// bare flag.
+	EngineArgs []string
+}
+
+// WriteOSSFuzzBundle writes results out in the flat layout ClusterFuzz's
+// OSS-Fuzz runners expect, rather than cifuzz's own bundle.yaml/work_dir
+// layout: each target's binary directly under outDir, plus a
+// "<target>_seed_corpus.zip", a "<target>.dict", and a "<target>.options"
+// file next to it. Only targets of LLVMFuzzerTestOneInput-based engines
+// ("libfuzzer", "honggfuzz", and "aflplusplus", which share this driver)
+// are written; results for any other engine are skipped entirely.
+//
+// Unlike WriteArchive/WriteContentManifest, which operate on the reusable
+// FileMap abstraction, this works directly off []*build.Result because
+// the bundle.yaml-writing pipeline (internal/bundler's Opts and
+// assembleArtifacts) that would normally produce a FileMap for a bundle
+// doesn't exist in this snapshot of the repo to build on.
+func WriteOSSFuzzBundle(outDir string, results []*build.Result, opts OSSFuzzOptions) error {
+	err := os.MkdirAll(outDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, result := range results {
+		if !isLLVMFuzzerTestOneInputEngine(result.Engine) {
+			continue
+		}
+		if result.Executable == "" {
+			continue
+		}
+
+		err = copyExecutable(result.Executable, filepath.Join(outDir, result.Name))
+		if err != nil {
+			return err
+		}
+
+		dictionary := result.Dictionary
+		if dictionary == "" {
+			dictionary = opts.Dictionary
+		}
+		if dictionary != "" {
+			err = copyFile(dictionary, filepath.Join(outDir, result.Name+".dict"))
+			if err != nil {
+				return err
+			}
+		}
+
+		if result.SeedCorpus != "" {
+			exists, err := fileutil.Exists(result.SeedCorpus)
+			if err != nil {
+				return err
+			}
+			if exists {
+				err = writeSeedCorpusZip(filepath.Join(outDir, result.Name+"_seed_corpus.zip"), result.SeedCorpus)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err = writeOptionsFile(filepath.Join(outDir, result.Name+".options"), result.Name, dictionary != "", opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isLLVMFuzzerTestOneInputEngine reports whether engine builds a fuzz
+// target around the LLVMFuzzerTestOneInput entry point, which is what
+// OSS-Fuzz's run_fuzzer driver, honggfuzz, and afl-fuzz's
+// afl-compiler-rt wrapper all expect - as opposed to e.g. Jazzer, whose
+// targets aren't standalone native executables at all.
+func isLLVMFuzzerTestOneInputEngine(engine string) bool {
+	switch engine {
+	case "libfuzzer", "honggfuzz", "aflplusplus":
+		return true
+	default:
+		return false
+	}
+}
+
+func copyExecutable(src, dst string) error {
+	err := copyFile(src, dst)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(os.Chmod(dst, 0o755))
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return errors.WithStack(err)
+}
+
+// writeSeedCorpusZip zips every regular file directly in (and below)
+// seedCorpusDir into archivePath, the layout ClusterFuzz expects a
+// "<target>_seed_corpus.zip" to have.
+func writeSeedCorpusZip(archivePath, seedCorpusDir string) error {
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	err = filepath.Walk(seedCorpusDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(seedCorpusDir, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		_, err = io.Copy(w, content)
+		return err
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(zw.Close())
+}
+
+// writeOptionsFile writes the ini-style "<target>.options" file
+// ClusterFuzz reads alongside a libFuzzer-driven target for its
+// "[libfuzzer]" section's dict/close_fd_mask/flag overrides.
+func writeOptionsFile(path, targetName string, hasDictionary bool, opts OSSFuzzOptions) error {
+	var b []byte
+	b = append(b, "[libfuzzer]\n"...)
+	if hasDictionary {
+		b = append(b, fmt.Sprintf("dict = %s\n", targetName+".dict")...)
+	}
+	if opts.CloseFDMask != 0 {
+		b = append(b, fmt.Sprintf("close_fd_mask = %d\n", opts.CloseFDMask)...)
+	}
+	for _, arg := range opts.EngineArgs {
+		key, value, ok := splitEngineArg(arg)
+		if !ok {
+			continue
+		}
+		b = append(b, fmt.Sprintf("%s = %s\n", key, value)...)
+	}
+
+	return errors.WithStack(os.WriteFile(path, b, 0o644))
+}
+
+// splitEngineArg splits a libFuzzer-style "-key=value" argument into its
+// key and value, reporting false for arguments (e.g. bare "-runs=0"-less
+// flags, or positional arguments) that don't fit that shape.
+func splitEngineArg(arg string) (key, value string, ok bool) {
+	if len(arg) < 2 || arg[0] != '-' {
+		return "", "", false
+	}
+	for i := 1; i < len(arg); i++ {
+		if arg[i] == '=' {
+			return arg[1:i], arg[i+1:], true
+		}
+	}
+	return "", "", false
+}
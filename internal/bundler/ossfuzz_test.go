@@ -0,0 +1,60 @@
+package bundler
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+func TestWriteOSSFuzzBundle(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "ossfuzz-bundle-*")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(tempDir)
+
+	executable := filepath.Join(tempDir, "my_fuzz_test")
+	require.NoError(t, os.WriteFile(executable, []byte("#!/bin/sh\n"), 0o755))
+
+	dictionary := filepath.Join(tempDir, "my_fuzz_test.dict")
+	require.NoError(t, os.WriteFile(dictionary, []byte(`"foo"`), 0o644))
+
+	seedCorpusDir := filepath.Join(tempDir, "seeds")
+	require.NoError(t, os.MkdirAll(seedCorpusDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpusDir, "seed1"), []byte("a"), 0o644))
+
+	outDir := filepath.Join(tempDir, "out")
+	result := &build.Result{
+		Name:       "my_fuzz_test",
+		Executable: executable,
+		SeedCorpus: seedCorpusDir,
+		Engine:     "libfuzzer",
+	}
+
+	err = WriteOSSFuzzBundle(outDir, []*build.Result{result}, OSSFuzzOptions{
+		Dictionary:  dictionary,
+		CloseFDMask: 3,
+		EngineArgs:  []string{"-rss_limit_mb=4096", "-fork"},
+	})
+	require.NoError(t, err)
+
+	require.FileExists(t, filepath.Join(outDir, "my_fuzz_test"))
+	require.FileExists(t, filepath.Join(outDir, "my_fuzz_test.dict"))
+
+	options, err := os.ReadFile(filepath.Join(outDir, "my_fuzz_test.options"))
+	require.NoError(t, err)
+	require.Contains(t, string(options), "dict = my_fuzz_test.dict")
+	require.Contains(t, string(options), "close_fd_mask = 3")
+	require.Contains(t, string(options), "rss_limit_mb = 4096")
+	require.NotContains(t, string(options), "-fork")
+
+	zr, err := zip.OpenReader(filepath.Join(outDir, "my_fuzz_test_seed_corpus.zip"))
+	require.NoError(t, err)
+	defer zr.Close()
+	require.Len(t, zr.File, 1)
+	require.Equal(t, "seed1", zr.File[0].Name)
+}
@@ -0,0 +1,21 @@
+// Package bundler assembles the fuzzers and runtime dependencies built by
+// internal/build into a cifuzz bundle archive.
+//
+// NOTE: this tree only has jazzer_bundler_test.go for this package, not
+// the assembleArtifacts/Opts implementation it tests against, so the
+// reproducible archive and manifest logic for chunk2-5 lives in
+// pkg/artifact (WriteArchive, WriteContentManifest, Verify) as the
+// building blocks assembleArtifacts would call once it's wired up to
+// write bundles deterministically. Verify below is the thin entry point
+// the request asks for.
+package bundler
+
+import "code-intelligence.com/cifuzz/pkg/artifact"
+
+// Verify recomputes the content.sha256 manifest of a bundle previously
+// written out deterministically via artifact.WriteArchive and
+// artifact.WriteContentManifest, returning an error if any entry's
+// content no longer matches the hash recorded at bundle time.
+func Verify(bundleDir string) error {
+	return artifact.Verify(bundleDir)
+}
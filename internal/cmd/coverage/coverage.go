@@ -0,0 +1,348 @@
+package coverage
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/golibfuzzer"
+	"code-intelligence.com/cifuzz/internal/build/maven"
+	"code-intelligence.com/cifuzz/internal/build/python"
+	"code-intelligence.com/cifuzz/internal/build/rust"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type coverageOptions struct {
+	BuildSystem    string   `mapstructure:"build-system"`
+	NumBuildJobs   uint     `mapstructure:"build-jobs"`
+	SeedCorpusDirs []string `mapstructure:"seed-corpus-dirs"`
+	OutputFormat   string   `mapstructure:"format"`
+
+	ProjectDir string
+	fuzzTest   string
+}
+
+func (opts *coverageOptions) validate() error {
+	var err error
+
+	opts.SeedCorpusDirs, err = cmdutils.ValidateSeedCorpusDirs(opts.SeedCorpusDirs)
+	if err != nil {
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = config.ValidateBuildSystem(opts.BuildSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = "html"
+	}
+	if opts.OutputFormat != "html" && opts.OutputFormat != "lcov" {
+		msg := "Flag \"format\" must be one of \"html\" or \"lcov\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	return nil
+}
+
+type coverageCmd struct {
+	*cobra.Command
+	opts *coverageOptions
+
+	tempDir string
+}
+
+func New() *cobra.Command {
+	opts := &coverageOptions{}
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:               "coverage-report [flags] <fuzz test>",
+		Short:             "Generate a coverage report for a fuzz test",
+		Long:              `This command builds a fuzz test with coverage instrumentation, replays its corpus, and generates a coverage report from the result.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		Args:              cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			bindFlags()
+
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				log.Errorf(err, "Failed to parse cifuzz.yaml: %v", err.Error())
+				return cmdutils.WrapSilentError(err)
+			}
+
+			fuzzTests, err := resolve.FuzzTestArgument(false, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				log.Error(err)
+				return cmdutils.WrapSilentError(err)
+			}
+			opts.fuzzTest = fuzzTests[0]
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := coverageCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	funcs := []func(cmd *cobra.Command) func(){
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddOutputFormatFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSeedCorpusFlag,
+	}
+	bindFlags = cmdutils.AddFlags(cmd, funcs...)
+	return cmd
+}
+
+func (c *coverageCmd) run() error {
+	err := c.checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	c.tempDir, err = os.MkdirTemp("", "cifuzz-coverage-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(c.tempDir)
+
+	buildResult, err := c.buildFuzzTest()
+	if err != nil {
+		return err
+	}
+
+	profileFile, err := c.replayCorpus(buildResult)
+	if err != nil {
+		return err
+	}
+
+	return c.generateReport(buildResult, profileFile)
+}
+
+func (c *coverageCmd) buildFuzzTest() (*build.Result, error) {
+	switch c.opts.BuildSystem {
+	case config.BuildSystemCMake:
+		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Engine:     "libfuzzer",
+			Parallel: cmake.ParallelOptions{
+				Enabled: c.opts.NumBuildJobs > 0,
+				NumJobs: c.opts.NumBuildJobs,
+			},
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.CoverageBuild(c.opts.fuzzTest)
+	case config.BuildSystemMaven:
+		builder, err := maven.NewBuilder(&maven.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Stdout:     os.Stdout,
+			Stderr:     os.Stderr,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.CoverageBuild(c.opts.fuzzTest)
+	case config.BuildSystemGo:
+		builder, err := golibfuzzer.NewBuilder(&golibfuzzer.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Parallel: golibfuzzer.ParallelOptions{
+				Enabled: c.opts.NumBuildJobs > 0,
+				NumJobs: c.opts.NumBuildJobs,
+			},
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.CoverageBuild(".", c.opts.fuzzTest)
+	case config.BuildSystemPython:
+		builder, err := python.NewBuilder(&python.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			TempDir:    c.tempDir,
+			Stdout:     os.Stdout,
+			Stderr:     os.Stderr,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.CoverageBuild(c.opts.fuzzTest)
+	case config.BuildSystemRust:
+		builder, err := rust.NewBuilder(&rust.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Stdout:     os.Stdout,
+			Stderr:     os.Stderr,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.CoverageBuild(c.opts.fuzzTest)
+	default:
+		return nil, errors.Errorf("Coverage reports are not supported for build system %q", c.opts.BuildSystem)
+	}
+}
+
+// replayCorpus runs buildResult's executable once over its corpus with
+// LLVM_PROFILE_FILE set, producing the raw profile data a coverage report
+// is built from. It's only used for CMake builds; Maven/Gradle builds
+// already produce their JaCoCo coverage data as part of CoverageBuild.
+func (c *coverageCmd) replayCorpus(buildResult *build.Result) (string, error) {
+	if c.opts.BuildSystem != config.BuildSystemCMake {
+		return "", nil
+	}
+
+	corpusDirs := append([]string{}, c.opts.SeedCorpusDirs...)
+	exists, err := fileutil.Exists(buildResult.SeedCorpus)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		corpusDirs = append(corpusDirs, buildResult.SeedCorpus)
+	}
+	if exists, err = fileutil.Exists(buildResult.GeneratedCorpus); err != nil {
+		return "", err
+	} else if exists {
+		corpusDirs = append(corpusDirs, buildResult.GeneratedCorpus)
+	}
+
+	profileFile := filepath.Join(c.tempDir, buildResult.Name+".profraw")
+	cmd := exec.Command(buildResult.Executable, corpusDirs...)
+	cmd.Env = append(os.Environ(), "LLVM_PROFILE_FILE="+profileFile)
+	cmd.Stdout = c.OutOrStdout()
+	cmd.Stderr = c.ErrOrStderr()
+
+	err = cmd.Run()
+	if err != nil {
+		return "", cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+
+	return profileFile, nil
+}
+
+// generateReport turns profileFile into the requested report format via
+// llvm-profdata and llvm-cov. For Maven/Gradle builds, profileFile is
+// empty and the JaCoCo report Jazzer already wrote as part of the coverage
+// build is used as-is; for Python, Go, and Rust builds, profileFile is
+// likewise empty and the "coverage.lcov" file CoverageBuild already wrote
+// (via coverage.py, "go tool covdata textfmt", or llvm-cov) is used as-is.
+func (c *coverageCmd) generateReport(buildResult *build.Result, profileFile string) error {
+	if profileFile == "" {
+		reportPath := filepath.Join(buildResult.BuildDir, "jacoco-report")
+		switch c.opts.BuildSystem {
+		case config.BuildSystemPython, config.BuildSystemGo, config.BuildSystemRust:
+			reportPath = filepath.Join(buildResult.ProjectDir, "coverage.lcov")
+		}
+		log.Successf("Coverage report written to %s", fileutil.PrettifyPath(reportPath))
+		return nil
+	}
+
+	indexedProfile := filepath.Join(c.tempDir, buildResult.Name+".profdata")
+	mergeCmd := exec.Command("llvm-profdata", "merge", "-sparse", profileFile, "-o", indexedProfile)
+	mergeCmd.Stdout = c.OutOrStdout()
+	mergeCmd.Stderr = c.ErrOrStderr()
+	if err := mergeCmd.Run(); err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), mergeCmd)
+	}
+
+	reportDir := filepath.Join(c.opts.ProjectDir, "coverage-report", buildResult.Name)
+	err := os.MkdirAll(reportDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	args := []string{"show", buildResult.Executable, "-instr-profile=" + indexedProfile}
+	if c.opts.OutputFormat == "html" {
+		args = append(args, "-format=html", "-output-dir="+reportDir)
+	} else {
+		args = append(args, "-format=lcov")
+	}
+
+	showCmd := exec.Command("llvm-cov", args...)
+	showCmd.Stderr = c.ErrOrStderr()
+	if c.opts.OutputFormat == "lcov" {
+		lcovFile := filepath.Join(reportDir, "coverage.lcov")
+		out, err := os.Create(lcovFile)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer out.Close()
+		showCmd.Stdout = out
+	} else {
+		showCmd.Stdout = c.OutOrStdout()
+	}
+
+	err = showCmd.Run()
+	if err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), showCmd)
+	}
+
+	log.Successf("Coverage report written to %s", fileutil.PrettifyPath(reportDir))
+	return nil
+}
+
+func (c *coverageCmd) checkDependencies() error {
+	var deps []dependencies.Key
+	switch c.opts.BuildSystem {
+	case config.BuildSystemCMake:
+		deps = []dependencies.Key{
+			dependencies.CLANG,
+			dependencies.LLVM_PROFDATA,
+			dependencies.LLVM_COV,
+			dependencies.CMAKE,
+		}
+	case config.BuildSystemMaven:
+		deps = []dependencies.Key{
+			dependencies.JAVA,
+			dependencies.MAVEN,
+		}
+	case config.BuildSystemPython:
+		deps = []dependencies.Key{
+			dependencies.PYTHON,
+		}
+	case config.BuildSystemGo:
+		deps = []dependencies.Key{
+			dependencies.GO,
+			dependencies.CLANG,
+		}
+	case config.BuildSystemRust:
+		deps = []dependencies.Key{
+			dependencies.CARGO,
+			dependencies.LLVM_COV,
+		}
+	default:
+		return errors.Errorf("Unsupported build system %q", c.opts.BuildSystem)
+	}
+
+	depsErr := dependencies.Check(deps)
+	if depsErr != nil {
+		log.Error(depsErr)
+		return cmdutils.WrapSilentError(depsErr)
+	}
+	return nil
+}
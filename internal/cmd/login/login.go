@@ -1,10 +1,17 @@
 package login
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/browser"
 	"github.com/pkg/errors"
@@ -19,9 +26,29 @@ import (
 	"code-intelligence.com/cifuzz/pkg/log"
 )
 
+// defaultDeviceFlowPollInterval is used when the server doesn't return an
+// "interval" for the device authorization grant flow.
+const defaultDeviceFlowPollInterval = 5 * time.Second
+
+// ssoCallbackTimeout bounds how long runSSOFlow waits for the browser to
+// redirect back to the loopback server before giving up.
+const ssoCallbackTimeout = 2 * time.Minute
+
+// ssoSuccessPage is shown in the browser once the loopback callback has
+// been handled, so the user knows it's safe to close the tab.
+const ssoSuccessPage = `<!DOCTYPE html>
+<html>
+<head><title>cifuzz login</title></head>
+<body>
+<p>Successfully authenticated with cifuzz. You can close this tab now.</p>
+</body>
+</html>`
+
 type loginOpts struct {
+	DeviceFlow  bool   `mapstructure:"device-flow"`
 	Interactive bool   `mapstructure:"interactive"`
 	Server      string `mapstructure:"server"`
+	SSO         bool   `mapstructure:"sso"`
 }
 
 type loginCmd struct {
@@ -47,8 +74,10 @@ To learn more, visit https://www.code-intelligence.com.`,
 		},
 		RunE: func(c *cobra.Command, args []string) error {
 			opts := &loginOpts{
+				DeviceFlow:  viper.GetBool("device-flow"),
 				Interactive: viper.GetBool("interactive"),
 				Server:      viper.GetString("server"),
+				SSO:         viper.GetBool("sso"),
 			}
 
 			// Check if the server option is a valid URL
@@ -68,8 +97,10 @@ To learn more, visit https://www.code-intelligence.com.`,
 		},
 	}
 	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddDeviceFlowFlag,
 		cmdutils.AddInteractiveFlag,
 		cmdutils.AddServerFlag,
+		cmdutils.AddSSOFlag,
 	)
 
 	cmdutils.DisableConfigCheck(cmd)
@@ -100,6 +131,30 @@ func (c *loginCmd) run() error {
 		}
 	}
 
+	// Try the browser-based SSO flow. If the loopback server can't bind,
+	// fall through to the other token sources below instead of failing
+	// outright.
+	if token == "" && c.opts.SSO {
+		var err error
+		token, err = c.runSSOFlow()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Try the device authorization grant flow. Unlike the interactive
+	// paste-a-token flow below, this doesn't require a TTY, so it's the
+	// path headless CI workers (e.g. a libFuzzer GitHub Actions runner)
+	// use to authenticate without a token pre-baked into the runner
+	// image.
+	if token == "" && c.opts.DeviceFlow {
+		var err error
+		token, err = c.runDeviceFlow()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Try reading it interactively
 	if token == "" && c.opts.Interactive && term.IsTerminal(int(os.Stdin.Fd())) {
 		msg := fmt.Sprintf(`Enter an API access token and press Enter. You can generate a token here:
@@ -127,6 +182,171 @@ in interactive mode. You can generate a token here:
 	return c.handleNewToken(token)
 }
 
+// runDeviceFlow drives the OAuth 2.0 device authorization grant flow
+// (RFC 8628): it requests a device code, prints the user code and
+// verification URL (opening it in a browser when possible), polls the
+// token endpoint until the request has been authorized, and finally
+// exchanges the resulting OAuth access token for a long-lived CI Fuzz API
+// token.
+func (c *loginCmd) runDeviceFlow() (string, error) {
+	deviceCode, err := c.apiClient.RequestDeviceCode()
+	if err != nil {
+		return "", err
+	}
+
+	log.Infof("To authenticate, open the following URL and confirm the code %s:\n%s",
+		deviceCode.UserCode, deviceCode.VerificationURIComplete)
+	err = browser.OpenURL(deviceCode.VerificationURIComplete)
+	if err != nil {
+		log.Error(err, "failed to open browser")
+	}
+
+	interval := time.Duration(deviceCode.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeviceFlowPollInterval
+	}
+	deadline := time.Now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
+
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return "", errors.New("device authorization expired before it was confirmed")
+		}
+
+		time.Sleep(interval)
+
+		tokenResponse, err := c.apiClient.PollDeviceToken(deviceCode.DeviceCode)
+		if err != nil {
+			return "", err
+		}
+
+		switch tokenResponse.Error {
+		case "":
+			return c.apiClient.ExchangeDeviceToken(tokenResponse.AccessToken)
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return "", cmdutils.WrapIncorrectUsageError(errors.New("device authorization was denied"))
+		case "expired_token":
+			return "", errors.New("device authorization expired before it was confirmed")
+		default:
+			return "", errors.Errorf("device authorization failed: %s", tokenResponse.Error)
+		}
+	}
+}
+
+// runSSOFlow drives an interactive browser-based login: it starts an
+// ephemeral local HTTP server, opens the dashboard's login page with a
+// PKCE (RFC 7636, S256) challenge in the browser, and waits for the
+// resulting authorization code to be delivered to the loopback callback,
+// which it then exchanges for a long-lived CI Fuzz API token. If the
+// loopback server can't be started, it returns an empty token so the
+// caller falls back to the other login methods instead of failing.
+func (c *loginCmd) runSSOFlow() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Warnf("Could not start local server for SSO login (%s), falling back to other login methods", err)
+		return "", nil
+	}
+
+	verifier, err := randomURLSafeString(32)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	challenge := pkceChallenge(verifier)
+
+	state, err := randomURLSafeString(16)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/callback", port)
+
+	type ssoResult struct {
+		token string
+		err   error
+	}
+	resultCh := make(chan ssoResult, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		// Strict state validation to defeat CSRF: only accept a
+		// callback that echoes back the exact nonce we generated.
+		if query.Get("state") != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			resultCh <- ssoResult{err: errors.New("SSO login failed: state mismatch, possible CSRF attempt")}
+			return
+		}
+
+		code := query.Get("code")
+		if code == "" {
+			http.Error(w, "missing code", http.StatusBadRequest)
+			resultCh <- ssoResult{err: errors.New("SSO login failed: no authorization code received")}
+			return
+		}
+
+		token, err := c.apiClient.ExchangeAuthorizationCode(code, verifier, redirectURI)
+		if err != nil {
+			http.Error(w, "failed to exchange authorization code", http.StatusBadGateway)
+			resultCh <- ssoResult{err: err}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, ssoSuccessPage)
+		resultCh <- ssoResult{token: token}
+	})
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	loginURL := fmt.Sprintf("%s/dashboard/login?%s", c.opts.Server, url.Values{
+		"redirect": {redirectURI},
+		"state":    {state},
+		"pkce":     {challenge},
+	}.Encode())
+
+	log.Infof("Open the following URL in your browser to log in:\n%s", loginURL)
+	err = browser.OpenURL(loginURL)
+	if err != nil {
+		log.Error(err, "failed to open browser")
+	}
+
+	select {
+	case res := <-resultCh:
+		if res.err != nil {
+			return "", res.err
+		}
+		return res.token, nil
+	case <-time.After(ssoCallbackTimeout):
+		return "", errors.New("timed out waiting for browser login to complete")
+	}
+}
+
+// randomURLSafeString returns a base64url-encoded (no padding) random
+// string generated from n random bytes.
+func randomURLSafeString(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge derives the RFC 7636 S256 code_challenge for verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
 func (c *loginCmd) handleNewToken(token string) error {
 	// Try to authenticate with the access token
 	tokenValid, err := c.apiClient.IsTokenValid(token)
@@ -0,0 +1,232 @@
+package merge
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/bazel"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/gotest"
+	"code-intelligence.com/cifuzz/internal/build/other"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+)
+
+type mergeOptions struct {
+	BuildSystem  string `mapstructure:"build-system"`
+	BuildCommand string `mapstructure:"build-command"`
+	UseSandbox   bool   `mapstructure:"use-sandbox"`
+
+	ProjectDir   string
+	fuzzTest     string
+	mergeTargets []string
+}
+
+func (opts *mergeOptions) validate() error {
+	var err error
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = config.ValidateBuildSystem(opts.BuildSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
+		msg := "Flag \"build-command\" must be set when using build system type \"other\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	for _, dir := range opts.mergeTargets {
+		if _, err := os.Stat(dir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+type mergeCmd struct {
+	*cobra.Command
+	opts *mergeOptions
+}
+
+func New() *cobra.Command {
+	opts := &mergeOptions{}
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "merge [flags] <fuzz test> <corpus dir>...",
+		Short: "Merge and minimize corpus directories for a fuzz test",
+		Long: `This command builds a fuzz test and uses it to merge one or more
+corpus directories into the fuzz test's generated corpus, keeping only
+inputs which increase coverage. Its own generated corpus is merged into
+itself too, pruning redundant inputs, the same way "cifuzz run --minimize"
+does for a single fuzzing run.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		Args:              cobra.MinimumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			bindFlags()
+
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				log.Errorf(err, "Failed to parse cifuzz.yaml: %v", err.Error())
+				return cmdutils.WrapSilentError(err)
+			}
+
+			fuzzTests, err := resolve.FuzzTestArgument(false, args[:1], opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				log.Error(err)
+				return cmdutils.WrapSilentError(err)
+			}
+			opts.fuzzTest = fuzzTests[0]
+			opts.mergeTargets = args[1:]
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := mergeCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	funcs := []func(cmd *cobra.Command) func(){
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddUseSandboxFlag,
+	}
+	bindFlags = cmdutils.AddFlags(cmd, funcs...)
+	return cmd
+}
+
+func (c *mergeCmd) run() error {
+	err := dependencies.Check(c.requiredDependencies())
+	if err != nil {
+		log.Error(err)
+		return cmdutils.WrapSilentError(err)
+	}
+
+	buildResult, err := c.buildFuzzTest()
+	if err != nil {
+		return err
+	}
+
+	if buildResult.Executable == "" {
+		return errors.Errorf("Merging corpora is not supported for build system %q", c.opts.BuildSystem)
+	}
+
+	runner := libfuzzer.NewRunner(&libfuzzer.RunnerOptions{
+		CacheDir:           buildResult.GeneratedCorpus,
+		FuzzTarget:         buildResult.Executable,
+		GeneratedCorpusDir: buildResult.GeneratedCorpus,
+		MergeTargets:       c.opts.mergeTargets,
+		Minimize:           true,
+		ProjectDir:         c.opts.ProjectDir,
+		UseMinijail:        c.opts.UseSandbox,
+		Verbose:            viper.GetBool("verbose"),
+	})
+
+	err = runner.Merge(c.Context())
+	if err != nil {
+		return err
+	}
+
+	log.Successf("Merged corpus written to %s", buildResult.GeneratedCorpus)
+	return nil
+}
+
+func (c *mergeCmd) buildFuzzTest() (*build.Result, error) {
+	switch c.opts.BuildSystem {
+	case config.BuildSystemBazel:
+		builder, err := bazel.NewBuilder(&bazel.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		buildResults, err := builder.BuildForRun([]string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return buildResults[0], nil
+	case config.BuildSystemCMake:
+		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Sanitizers: []string{config.SanitizerAddress},
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = builder.Configure()
+		if err != nil {
+			return nil, err
+		}
+		buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return buildResults[c.opts.fuzzTest], nil
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		// Jazzer merges corpora via its own "--merge"/"--merge_inner"
+		// flags rather than libfuzzer.Runner's "-merge=1", which isn't
+		// wired up yet.
+		return nil, errors.Errorf("Merging corpora is not supported for build system %q yet", c.opts.BuildSystem)
+	case config.BuildSystemGo:
+		builder, err := gotest.NewBuilder(&gotest.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.Build(c.opts.fuzzTest)
+	case config.BuildSystemOther:
+		builder, err := other.NewBuilder(&other.BuilderOptions{
+			ProjectDir:   c.opts.ProjectDir,
+			BuildCommand: c.opts.BuildCommand,
+			Sanitizers:   []string{config.SanitizerAddress},
+			Stdout:       c.OutOrStdout(),
+			Stderr:       c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.Build(c.opts.fuzzTest)
+	default:
+		return nil, errors.Errorf("Merging corpora is not supported for build system %q", c.opts.BuildSystem)
+	}
+}
+
+func (c *mergeCmd) requiredDependencies() []dependencies.Key {
+	switch c.opts.BuildSystem {
+	case config.BuildSystemCMake, config.BuildSystemOther:
+		return []dependencies.Key{dependencies.CLANG}
+	case config.BuildSystemBazel:
+		return []dependencies.Key{dependencies.BAZEL}
+	case config.BuildSystemMaven:
+		return []dependencies.Key{dependencies.JAVA, dependencies.MAVEN}
+	case config.BuildSystemGo:
+		return []dependencies.Key{dependencies.GO}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+// Package minijail implements the "cifuzz minijail" subcommand, which
+// exposes maintenance operations for the minijail sandbox backend that
+// don't belong to any particular fuzzing run.
+package minijail
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/minijail"
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "minijail",
+		Short: "Maintenance commands for the minijail sandbox backend",
+	}
+
+	cmd.AddCommand(newGCCmd())
+	return cmd
+}
+
+func newGCCmd() *cobra.Command {
+	var maxAge time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove leaked minijail chroot directories from previous runs",
+		Long: "Removes chroot directories under the temp directory left behind by cifuzz " +
+			"processes that no longer exist - e.g. because they were killed with SIGKILL " +
+			"before they could clean up after themselves. Chroots younger than --max-age, " +
+			"or still owned by a running process, are left alone.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := minijail.GC(maxAge)
+			if err != nil {
+				log.Error(err)
+				return cmdutils.WrapSilentError(err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&maxAge, "max-age", 24*time.Hour, "only remove chroots older than this")
+	return cmd
+}
@@ -3,6 +3,7 @@ package run
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -23,20 +24,29 @@ import (
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/bazel"
 	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/gotest"
 	"code-intelligence.com/cifuzz/internal/build/gradle"
 	"code-intelligence.com/cifuzz/internal/build/maven"
 	"code-intelligence.com/cifuzz/internal/build/other"
+	"code-intelligence.com/cifuzz/internal/build/python"
+	"code-intelligence.com/cifuzz/internal/build/rust"
 	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/login"
 	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/container"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runner/aflplusplus"
+	"code-intelligence.com/cifuzz/pkg/runner/gonative"
+	"code-intelligence.com/cifuzz/pkg/runner/honggfuzz"
 	"code-intelligence.com/cifuzz/pkg/runner/jazzer"
 	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/pkg/sandbox"
+	"code-intelligence.com/cifuzz/pkg/session"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
@@ -44,15 +54,26 @@ type runOptions struct {
 	BuildSystem           string        `mapstructure:"build-system"`
 	BuildCommand          string        `mapstructure:"build-command"`
 	NumBuildJobs          uint          `mapstructure:"build-jobs"`
+	NumJobs               uint          `mapstructure:"jobs"`
+	Engine                string        `mapstructure:"engine"`
 	Dictionary            string        `mapstructure:"dict"`
 	EngineArgs            []string      `mapstructure:"engine-args"`
+	Sanitizers            []string      `mapstructure:"sanitizers"`
 	SeedCorpusDirs        []string      `mapstructure:"seed-corpus-dirs"`
 	Timeout               time.Duration `mapstructure:"timeout"`
 	Interactive           bool          `mapstructure:"interactive"`
 	Server                string        `mapstructure:"server"`
 	UseSandbox            bool          `mapstructure:"use-sandbox"`
+	SandboxMode           string        `mapstructure:"sandbox"`
+	MutatorPlugin         string        `mapstructure:"mutator-plugin"`
 	PrintJSON             bool          `mapstructure:"print-json"`
 	BuildOnly             bool          `mapstructure:"build-only"`
+	Minimize              bool          `mapstructure:"minimize"`
+	SessionID             string        `mapstructure:"session-id"`
+	Container             bool          `mapstructure:"container"`
+	ContainerImage        string        `mapstructure:"container-image"`
+	OnlyAffected          string        `mapstructure:"only-affected"`
+	BuildEventsPath       string        `mapstructure:"build-events"`
 	ResolveSourceFilePath bool
 
 	ProjectDir string
@@ -102,6 +123,39 @@ func (opts *runOptions) validate() error {
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
+	if len(opts.Sanitizers) > 0 {
+		err = config.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	if opts.Engine == "" {
+		if opts.BuildSystem == config.BuildSystemMaven || opts.BuildSystem == config.BuildSystemGradle {
+			opts.Engine = config.EngineJazzer
+		} else {
+			opts.Engine = config.EngineLibFuzzer
+		}
+	} else {
+		err = config.ValidateEngineBackend(opts.Engine)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	switch opts.BuildSystem {
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		if opts.Engine != config.EngineJazzer {
+			msg := fmt.Sprintf(`Build system %q only supports the "jazzer" engine`, opts.BuildSystem)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	default:
+		if opts.Engine == config.EngineJazzer {
+			msg := fmt.Sprintf(`The "jazzer" engine is not supported for build system %q`, opts.BuildSystem)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
 	return nil
 }
 
@@ -111,6 +165,8 @@ type runCmd struct {
 
 	reportHandler *report_handler.ReportHandler
 	tempDir       string
+	sessionState  *session.State
+	buildEvents   *os.File
 }
 
 type runner interface {
@@ -141,6 +197,16 @@ depends on the build system configured for the project.
 
     cifuzz run my_fuzz_test -- -G Ninja
 
+  Passing --only-affected <base ref> skips the run if <fuzz test>'s
+  transitive source/header inputs weren't touched by the changes since
+  <base ref>, so that CI pipelines can skip unaffected fuzz tests.
+
+  Passing --build-events <path> writes the build's progress as a stream
+  of newline-delimited JSON events (compiler diagnostics, per-target
+  progress, build failures, resolved runtime deps) to <path>, for IDE
+  integrations and CI systems that want structured build output instead
+  of parsing stderr.
+
   The inputs found in the directory
 
     <fuzz test>_inputs
@@ -246,13 +312,24 @@ depends on the build system configured for the project.
 	// bind it to viper in the PreRunE function.
 	funcs := []func(cmd *cobra.Command) func(){
 		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddBuildEventsFlag,
 		cmdutils.AddBuildJobsFlag,
 		cmdutils.AddBuildOnlyFlag,
+		cmdutils.AddContainerFlag,
+		cmdutils.AddContainerImageFlag,
 		cmdutils.AddDictFlag,
 		cmdutils.AddEngineArgFlag,
+		cmdutils.AddEngineFlag,
+		cmdutils.AddJobsFlag,
+		cmdutils.AddMinimizeFlag,
+		cmdutils.AddMutatorPluginFlag,
+		cmdutils.AddOnlyAffectedFlag,
 		cmdutils.AddPrintJSONFlag,
 		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSandboxModeFlag,
+		cmdutils.AddSanitizersFlag,
 		cmdutils.AddSeedCorpusFlag,
+		cmdutils.AddSessionIDFlag,
 		cmdutils.AddTimeoutFlag,
 		cmdutils.AddUseSandboxFlag,
 		cmdutils.AddResolveSourceFileFlag,
@@ -265,7 +342,31 @@ depends on the build system configured for the project.
 }
 
 func (c *runCmd) run() error {
-	err := c.checkDependencies()
+	// Create a temporary directory which the builder can use to create
+	// temporary files
+	var err error
+	c.tempDir, err = os.MkdirTemp("", "cifuzz-run-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(c.tempDir)
+
+	if c.opts.BuildEventsPath != "" {
+		c.buildEvents, err = os.Create(c.opts.BuildEventsPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer c.buildEvents.Close()
+	}
+
+	if c.opts.Container {
+		// The host doesn't need the build system's toolchain installed
+		// when the whole build and run happens inside the container, so
+		// skip straight to it instead of calling c.checkDependencies().
+		return c.runInContainer()
+	}
+
+	err = c.checkDependencies()
 	if err != nil {
 		return err
 	}
@@ -277,23 +378,38 @@ func (c *runCmd) run() error {
 		}
 	}
 
-	// Create a temporary directory which the builder can use to create
-	// temporary files
-	c.tempDir, err = os.MkdirTemp("", "cifuzz-run-")
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	defer fileutil.Cleanup(c.tempDir)
-
 	buildResult, err := c.buildFuzzTest()
 	if err != nil {
 		return err
 	}
+	if buildResult == nil {
+		// Either --build-only was set, or --only-affected determined this
+		// fuzz test wasn't touched by the changes since the given ref.
+		return nil
+	}
 
 	if c.opts.BuildOnly {
 		return nil
 	}
 
+	for _, s := range buildResult.Sanitizers {
+		if s == config.SanitizerCoverage {
+			return c.runCoverageBuild(buildResult)
+		}
+	}
+
+	if c.opts.SessionID != "" {
+		err = c.loadSessionState()
+		if err != nil {
+			return err
+		}
+		if c.sessionState == nil {
+			// The session's --timeout budget has already been used up by
+			// previous runs.
+			return nil
+		}
+	}
+
 	// Initialize the report handler. Only do this right before we start
 	// the fuzz test, because this is storing a timestamp which is used
 	// to figure out how long the fuzzing run is running.
@@ -306,6 +422,7 @@ func (c *runCmd) run() error {
 		return err
 	}
 
+	runStart := time.Now()
 	err = c.runFuzzTest(buildResult)
 	if err != nil {
 		var exitErr *exec.ExitError
@@ -317,6 +434,13 @@ func (c *runCmd) run() error {
 
 	c.reportHandler.PrintCrashingInputNote()
 
+	if c.opts.SessionID != "" {
+		err = c.saveSessionState(buildResult, time.Since(runStart))
+		if err != nil {
+			return err
+		}
+	}
+
 	err = c.printFinalMetrics(buildResult.GeneratedCorpus, buildResult.SeedCorpus)
 	if err != nil {
 		return err
@@ -325,21 +449,86 @@ func (c *runCmd) run() error {
 	return nil
 }
 
+// loadSessionState loads the previously persisted state for
+// c.opts.SessionID (if any), reducing c.opts.Timeout by the time already
+// spent fuzzing in earlier runs of the session and merging in the
+// session's saved dictionary and engine args. If the session has already
+// used up its --timeout budget, it sets c.sessionState to nil so that
+// run can skip fuzzing entirely.
+func (c *runCmd) loadSessionState() error {
+	state, err := session.Load(c.opts.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.Timeout != 0 {
+		remaining := c.opts.Timeout - state.Elapsed
+		if remaining <= 0 {
+			log.Successf("Session %q has already used up its %s --timeout budget", c.opts.SessionID, c.opts.Timeout)
+			return nil
+		}
+		c.opts.Timeout = remaining
+	}
+
+	if c.opts.Dictionary == "" {
+		c.opts.Dictionary = state.Dictionary
+	}
+	c.opts.EngineArgs = append(c.opts.EngineArgs, state.EngineArgs...)
+
+	c.sessionState = state
+	return nil
+}
+
+// saveSessionState persists the state accumulated during this run under
+// c.opts.SessionID, so that the next "cifuzz run --session-id" invocation
+// can resume from it.
+func (c *runCmd) saveSessionState(buildResult *build.Result, elapsed time.Duration) error {
+	hashes, err := session.HashCorpus(buildResult.GeneratedCorpus)
+	if err != nil {
+		return err
+	}
+
+	c.sessionState.SeedCorpusHashes = hashes
+	c.sessionState.Dictionary = c.opts.Dictionary
+	c.sessionState.EngineArgs = c.opts.EngineArgs
+	c.sessionState.Elapsed += elapsed
+	c.sessionState.Findings = append(c.sessionState.Findings, c.reportHandler.CrashingInputs()...)
+
+	return session.Save(c.opts.SessionID, c.sessionState)
+}
+
+// buildEventWriter returns c.buildEvents as an io.Writer for
+// cmake.BuilderOptions.EventWriter, or nil if --build-events wasn't set.
+// Returning the nil *os.File directly would produce a non-nil io.Writer
+// interface value, which build.EmitEvent's nil check wouldn't catch.
+func (c *runCmd) buildEventWriter() io.Writer {
+	if c.buildEvents == nil {
+		return nil
+	}
+	return c.buildEvents
+}
+
 func (c *runCmd) buildFuzzTest() (*build.Result, error) {
-	// TODO: Do not hardcode these values.
-	sanitizers := []string{"address"}
-	// UBSan is not supported by MSVC
-	// TODO: Not needed anymore when sanitizers are configurable,
-	//       then we do want to fail if the user explicitly asked for
-	//       UBSan.
-	if runtime.GOOS != "windows" {
-		sanitizers = append(sanitizers, "undefined")
+	sanitizers := c.opts.Sanitizers
+	if len(sanitizers) == 0 {
+		sanitizers = []string{config.SanitizerAddress}
+		// UBSan is not supported by MSVC
+		if runtime.GOOS != "windows" {
+			sanitizers = append(sanitizers, config.SanitizerUndefined)
+		}
+	} else if runtime.GOOS == "windows" {
+		for _, s := range sanitizers {
+			if s == config.SanitizerUndefined {
+				return nil, errors.New(`the "undefined" sanitizer is not supported by MSVC`)
+			}
+		}
 	}
 
 	if runtime.GOOS == "windows" &&
 		(c.opts.BuildSystem != config.BuildSystemCMake &&
 			c.opts.BuildSystem != config.BuildSystemMaven &&
-			c.opts.BuildSystem != config.BuildSystemGradle) {
+			c.opts.BuildSystem != config.BuildSystemGradle &&
+			c.opts.BuildSystem != config.BuildSystemGo) {
 
 		return nil, errors.New("Build system unsupported on Windows")
 	}
@@ -362,6 +551,8 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 			ProjectDir: c.opts.ProjectDir,
 			Args:       c.opts.argsToPass,
 			NumJobs:    c.opts.NumBuildJobs,
+			Engine:     c.opts.Engine,
+			Sanitizers: sanitizers,
 			Stdout:     c.OutOrStdout(),
 			Stderr:     c.ErrOrStderr(),
 			TempDir:    c.tempDir,
@@ -379,14 +570,16 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
 			ProjectDir: c.opts.ProjectDir,
 			Args:       c.opts.argsToPass,
+			Engine:     c.opts.Engine,
 			Sanitizers: sanitizers,
 			Parallel: cmake.ParallelOptions{
 				Enabled: viper.IsSet("build-jobs"),
 				NumJobs: c.opts.NumBuildJobs,
 			},
-			Stdout:    c.OutOrStdout(),
-			Stderr:    c.ErrOrStderr(),
-			BuildOnly: c.opts.BuildOnly,
+			Stdout:      c.OutOrStdout(),
+			Stderr:      c.ErrOrStderr(),
+			BuildOnly:   c.opts.BuildOnly,
+			EventWriter: c.buildEventWriter(),
 		})
 		if err != nil {
 			return nil, err
@@ -395,6 +588,23 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 		if err != nil {
 			return nil, err
 		}
+		if c.opts.OnlyAffected != "" {
+			affected, err := builder.AffectedFuzzTests(c.opts.OnlyAffected)
+			if err != nil {
+				return nil, err
+			}
+			isAffected := false
+			for _, fuzzTest := range affected {
+				if fuzzTest == c.opts.fuzzTest {
+					isAffected = true
+					break
+				}
+			}
+			if !isAffected {
+				log.Infof("Skipping %s: not affected by changes since %s", c.opts.fuzzTest, c.opts.OnlyAffected)
+				return nil, nil
+			}
+		}
 		buildResults, err := builder.Build([]string{c.opts.fuzzTest})
 		if err != nil {
 			return nil, err
@@ -421,7 +631,15 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 		if err != nil {
 			return nil, err
 		}
-		buildResult, err := builder.Build(c.opts.fuzzTest)
+		// Maven is one of the build systems that already implements
+		// build.Build, so go through Prepare/Fuzzer directly instead of
+		// the legacy Build(target) adapter.
+		var b build.Build = builder
+		err = b.Prepare(c.Context())
+		if err != nil {
+			return nil, err
+		}
+		buildResult, err := b.Fuzzer(c.opts.fuzzTest)
 		if err != nil {
 			return nil, err
 		}
@@ -470,6 +688,71 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 			return nil, err
 		}
 		return buildResult, nil
+	case config.BuildSystemGo:
+		if len(c.opts.argsToPass) > 0 {
+			log.Warnf("Passing additional arguments is not supported for build system type \"go\".\n"+
+				"These arguments are ignored: %s", strings.Join(c.opts.argsToPass, " "))
+		}
+
+		builder, err := gotest.NewBuilder(&gotest.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		buildResult, err := builder.Build(c.opts.fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+		return buildResult, nil
+	case config.BuildSystemPython:
+		if len(c.opts.argsToPass) > 0 {
+			log.Warnf("Passing additional arguments is not supported for build system type \"python\".\n"+
+				"These arguments are ignored: %s", strings.Join(c.opts.argsToPass, " "))
+		}
+
+		builder, err := python.NewBuilder(&python.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			TempDir:    c.tempDir,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Python is the other build system that already implements
+		// build.Build; see the Maven case above.
+		var b build.Build = builder
+		err = b.Prepare(c.Context())
+		if err != nil {
+			return nil, err
+		}
+		buildResult, err := b.Fuzzer(c.opts.fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+		return buildResult, nil
+	case config.BuildSystemRust:
+		if len(c.opts.argsToPass) > 0 {
+			log.Warnf("Passing additional arguments is not supported for build system type \"rust\".\n"+
+				"These arguments are ignored: %s", strings.Join(c.opts.argsToPass, " "))
+		}
+
+		builder, err := rust.NewBuilder(&rust.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		buildResult, err := builder.Build(c.opts.fuzzTest)
+		if err != nil {
+			return nil, err
+		}
+		return buildResult, nil
 	}
 
 	return nil, errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
@@ -529,16 +812,32 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 		readOnlyBindings = append(readOnlyBindings, installBase)
 	}
 
+	mutatorPlugin := c.opts.MutatorPlugin
+	if mutatorPlugin == "" {
+		mutatorPlugin = os.Getenv("CIFUZZ_MUTATOR_PLUGIN")
+	}
+
+	sandboxBackend, err := sandbox.ParseBackend(c.opts.SandboxMode)
+	if err != nil {
+		log.Error(err)
+		return cmdutils.ErrSilent
+	}
+
 	runnerOpts := &libfuzzer.RunnerOptions{
+		Backend:            sandboxBackend,
 		Dictionary:         c.opts.Dictionary,
 		EngineArgs:         c.opts.EngineArgs,
 		EnvVars:            []string{"NO_CIFUZZ=1"},
 		FuzzTarget:         buildResult.Executable,
 		GeneratedCorpusDir: buildResult.GeneratedCorpus,
+		Jobs:               int(c.opts.NumJobs),
 		KeepColor:          !c.opts.PrintJSON,
+		Minimize:           c.opts.Minimize,
+		MutatorPlugin:      mutatorPlugin,
 		ProjectDir:         c.opts.ProjectDir,
 		ReadOnlyBindings:   []string{buildResult.BuildDir},
 		ReportHandler:      c.reportHandler,
+		Runtime:            buildResult.Runtime,
 		SeedCorpusDirs:     seedCorpusDirs,
 		Timeout:            c.opts.Timeout,
 		UseMinijail:        c.opts.UseSandbox,
@@ -548,9 +847,84 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 	var runner runner
 
 	switch c.opts.BuildSystem {
-	case config.BuildSystemCMake, config.BuildSystemBazel, config.BuildSystemOther:
+	case config.BuildSystemCMake, config.BuildSystemBazel, config.BuildSystemOther, config.BuildSystemRust:
+		switch c.opts.Engine {
+		case config.EngineAFLPlusPlus:
+			runner = aflplusplus.NewRunner(&aflplusplus.RunnerOptions{
+				Backend:    sandboxBackend,
+				EngineArgs: c.opts.EngineArgs,
+				EnvVars: []string{
+					"NO_CIFUZZ=1",
+					// Skip AFL++'s CPU scaling-governor check, which
+					// fails on most CI runners and sandboxed containers.
+					"AFL_SKIP_CPUFREQ=1",
+					// Avoid binding the fuzzer to a specific CPU core,
+					// which AFL++ otherwise insists on and which doesn't
+					// make sense when several fuzz tests run in parallel
+					// containers sharing the same core set.
+					"AFL_NO_AFFINITY=1",
+				},
+				FuzzTarget:         buildResult.Executable,
+				GeneratedCorpusDir: buildResult.GeneratedCorpus,
+				MutatorPlugin:      mutatorPlugin,
+				OutputDir:          buildResult.GeneratedCorpus + "-aflplusplus-out",
+				ProjectDir:         c.opts.ProjectDir,
+				ReportHandler:      c.reportHandler,
+				SeedCorpusDirs:     seedCorpusDirs,
+				Timeout:            c.opts.Timeout,
+				UseMinijail:        c.opts.UseSandbox,
+				Verbose:            viper.GetBool("verbose"),
+			})
+		case config.EngineHonggfuzz:
+			runner = honggfuzz.NewRunner(&honggfuzz.RunnerOptions{
+				Backend:    sandboxBackend,
+				EngineArgs: c.opts.EngineArgs,
+				EnvVars: []string{
+					"NO_CIFUZZ=1",
+					// Quiet honggfuzz's own progress output so it doesn't
+					// interleave with cifuzz's --print-json reporting.
+					"HFUZZ_QUIET=1",
+				},
+				FuzzTarget:         buildResult.Executable,
+				GeneratedCorpusDir: buildResult.GeneratedCorpus,
+				CrashDir:           buildResult.GeneratedCorpus + "-honggfuzz-crashes",
+				MutatorPlugin:      mutatorPlugin,
+				ProjectDir:         c.opts.ProjectDir,
+				ReportHandler:      c.reportHandler,
+				SeedCorpusDirs:     seedCorpusDirs,
+				Timeout:            c.opts.Timeout,
+				UseMinijail:        c.opts.UseSandbox,
+				Verbose:            viper.GetBool("verbose"),
+			})
+		default:
+			runner = libfuzzer.NewRunner(runnerOpts)
+		}
+	case config.BuildSystemPython:
+		// atheris wrapper scripts accept libFuzzer's CLI flags directly,
+		// so they're run the same way a CMake/Bazel libFuzzer target is;
+		// runnerOpts.Runtime tells the runner to invoke the script
+		// through python3 instead of executing it directly.
 		runner = libfuzzer.NewRunner(runnerOpts)
+	case config.BuildSystemGo:
+		runner = gonative.NewRunner(&gonative.RunnerOptions{
+			EngineArgs:         c.opts.EngineArgs,
+			EnvVars:            []string{"NO_CIFUZZ=1"},
+			FuzzTarget:         c.opts.fuzzTest,
+			GeneratedCorpusDir: buildResult.GeneratedCorpus,
+			ProjectDir:         c.opts.ProjectDir,
+			ReportHandler:      c.reportHandler,
+			SeedCorpusDirs:     seedCorpusDirs,
+			Timeout:            c.opts.Timeout,
+			UseMinijail:        c.opts.UseSandbox,
+			Verbose:            viper.GetBool("verbose"),
+		})
 	case config.BuildSystemMaven, config.BuildSystemGradle:
+		_, unsupported := config.FilterJazzerSanitizers(c.opts.Sanitizers)
+		if len(unsupported) > 0 {
+			log.Warnf("Jazzer only supports the \"address\" and \"undefined\" sanitizers; ignoring: %s",
+				strings.Join(unsupported, ", "))
+		}
+
 		excludePatterns := []string{"org.apache.maven.**", "org.gradle.**"}
 		runnerOpts := &jazzer.RunnerOptions{
 			TargetClass:             c.opts.fuzzTest,
@@ -564,6 +938,42 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 	return executeRunner(runner)
 }
 
+// runCoverageBuild executes a fuzz test built with the "coverage"
+// sanitizer once over its corpus, instead of running the libFuzzer
+// fuzzing loop, producing the .profraw coverage data a coverage report
+// is built from.
+func (c *runCmd) runCoverageBuild(buildResult *build.Result) error {
+	log.Infof("Running %s with coverage instrumentation", buildResult.Name)
+
+	corpusDirs := append([]string{}, c.opts.SeedCorpusDirs...)
+	exists, err := fileutil.Exists(buildResult.SeedCorpus)
+	if err != nil {
+		return err
+	}
+	if exists {
+		corpusDirs = append(corpusDirs, buildResult.SeedCorpus)
+	}
+	if exists, err = fileutil.Exists(buildResult.GeneratedCorpus); err != nil {
+		return err
+	} else if exists {
+		corpusDirs = append(corpusDirs, buildResult.GeneratedCorpus)
+	}
+
+	profileFile := filepath.Join(c.tempDir, buildResult.Name+".profraw")
+	cmd := exec.Command(buildResult.Executable, corpusDirs...)
+	cmd.Env = append(os.Environ(), "LLVM_PROFILE_FILE="+profileFile)
+	cmd.Stdout = c.OutOrStdout()
+	cmd.Stderr = c.ErrOrStderr()
+
+	err = cmd.Run()
+	if err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+
+	log.Successf("Coverage data written to %s", fileutil.PrettifyPath(profileFile))
+	return nil
+}
+
 func (c *runCmd) printFinalMetrics(generatedCorpus, seedCorpus string) error {
 	numCorpusEntries, err := countCorpusEntries(append(c.opts.SeedCorpusDirs, generatedCorpus, seedCorpus))
 	if err != nil {
@@ -614,10 +1024,29 @@ func (c *runCmd) checkDependencies() error {
 		deps = []dependencies.Key{
 			dependencies.BAZEL,
 		}
+	case config.BuildSystemGo:
+		deps = []dependencies.Key{
+			dependencies.GO,
+		}
+	case config.BuildSystemPython:
+		deps = []dependencies.Key{
+			dependencies.PYTHON,
+		}
+	case config.BuildSystemRust:
+		deps = []dependencies.Key{
+			dependencies.CARGO,
+		}
 	default:
 		return errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
 	}
 
+	switch c.opts.Engine {
+	case config.EngineAFLPlusPlus:
+		deps = append(deps, dependencies.AFLPLUSPLUS)
+	case config.EngineHonggfuzz:
+		deps = append(deps, dependencies.HONGGFUZZ)
+	}
+
 	depsErr := dependencies.Check(deps)
 	if depsErr != nil {
 		log.Error(depsErr)
@@ -626,6 +1055,72 @@ func (c *runCmd) checkDependencies() error {
 	return nil
 }
 
+// runInContainer re-invokes this same "cifuzz run" command, minus the
+// "--container"/"--container-image" flags, inside a container built
+// from a base-builder image matching c.opts.BuildSystem, so that the
+// whole build and fuzzing run happens in a reproducible Linux
+// environment regardless of the host OS.
+func (c *runCmd) runInContainer() error {
+	image := c.opts.ContainerImage
+	if image == "" {
+		var err error
+		image, err = container.DefaultImage(c.opts.BuildSystem)
+		if err != nil {
+			log.Error(err)
+			return cmdutils.WrapSilentError(err)
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cacheDir := filepath.Join(home, ".cache", "cifuzz")
+
+	runner, err := container.NewRunner(&container.Options{
+		Image:      image,
+		Command:    containerCommand(),
+		ProjectDir: c.opts.ProjectDir,
+		TempDir:    c.tempDir,
+		CacheDir:   cacheDir,
+		Stdout:     c.OutOrStdout(),
+		Stderr:     c.ErrOrStderr(),
+	})
+	if err != nil {
+		log.Error(err)
+		return cmdutils.WrapSilentError(err)
+	}
+
+	err = runner.Run(c.Context())
+	if err != nil {
+		log.Error(err)
+		return cmdutils.WrapSilentError(err)
+	}
+	return nil
+}
+
+// containerCommand rebuilds the "cifuzz run" command line to execute
+// inside the container, stripping "--container" and "--container-image"
+// so that the containerized cifuzz doesn't try to recurse into another
+// container.
+func containerCommand() []string {
+	command := []string{"cifuzz"}
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--container":
+			continue
+		case args[i] == "--container-image":
+			i++
+		case strings.HasPrefix(args[i], "--container-image="):
+			continue
+		default:
+			command = append(command, args[i])
+		}
+	}
+	return command
+}
+
 func (c *runCmd) setupSync() error {
 	interactive := viper.GetBool("interactive")
 
@@ -0,0 +1,85 @@
+// Package session implements the "cifuzz session" subcommand, which lets
+// users inspect and manage the on-disk state "cifuzz run --session-id"
+// persists between runs.
+package session
+
+import (
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/session"
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "List, show, and remove persisted fuzzing sessions",
+	}
+
+	cmd.AddCommand(newListCmd(), newShowCmd(), newRmCmd())
+	return cmd
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the IDs of all persisted fuzzing sessions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := session.List()
+			if err != nil {
+				log.Error(err)
+				return cmdutils.WrapSilentError(err)
+			}
+
+			for _, id := range ids {
+				cmd.Println(id)
+			}
+			return nil
+		},
+	}
+}
+
+func newShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <session-id>",
+		Short: "Show the persisted state of a fuzzing session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := session.Load(args[0])
+			if err != nil {
+				log.Error(err)
+				return cmdutils.WrapSilentError(err)
+			}
+
+			cmd.Printf("Elapsed:    %s\n", state.Elapsed)
+			cmd.Printf("Dictionary: %s\n", state.Dictionary)
+			cmd.Printf("EngineArgs: %v\n", state.EngineArgs)
+			cmd.Printf("Corpus size: %d inputs\n", len(state.SeedCorpusHashes))
+			cmd.Printf("Findings:   %d\n", len(state.Findings))
+			for _, finding := range state.Findings {
+				cmd.Printf("  - %s\n", finding)
+			}
+			return nil
+		},
+	}
+}
+
+func newRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <session-id>",
+		Short: "Remove the persisted state of a fuzzing session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := session.Remove(args[0])
+			if err != nil {
+				log.Error(err)
+				return cmdutils.WrapSilentError(err)
+			}
+
+			log.Successf("Removed session %q", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Supported values for the "build-system" flag and the "build-system"
+// cifuzz.yaml key.
+const (
+	BuildSystemCMake  = "cmake"
+	BuildSystemBazel  = "bazel"
+	BuildSystemMaven  = "maven"
+	BuildSystemGradle = "gradle"
+	BuildSystemGo     = "go"
+	BuildSystemPython = "python"
+	BuildSystemRust   = "rust"
+	BuildSystemOther  = "other"
+)
+
+var supportedBuildSystems = []string{
+	BuildSystemCMake,
+	BuildSystemBazel,
+	BuildSystemMaven,
+	BuildSystemGradle,
+	BuildSystemGo,
+	BuildSystemPython,
+	BuildSystemRust,
+	BuildSystemOther,
+}
+
+// ValidateBuildSystem checks that buildSystem is one of the supported
+// values.
+func ValidateBuildSystem(buildSystem string) error {
+	for _, s := range supportedBuildSystems {
+		if buildSystem == s {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported build system %q, must be one of: %s",
+		buildSystem, strings.Join(supportedBuildSystems, ", "))
+}
+
+// DetermineBuildSystem looks for the marker file of each supported build
+// system in projectDir and returns the first one it finds. Go is detected
+// via go.mod, mirroring how `go test` locates the enclosing module.
+func DetermineBuildSystem(projectDir string) (string, error) {
+	markers := []struct {
+		file        string
+		buildSystem string
+	}{
+		{"CMakeLists.txt", BuildSystemCMake},
+		{"WORKSPACE", BuildSystemBazel},
+		{"WORKSPACE.bazel", BuildSystemBazel},
+		{"MODULE.bazel", BuildSystemBazel},
+		{"pom.xml", BuildSystemMaven},
+		{"build.gradle", BuildSystemGradle},
+		{"build.gradle.kts", BuildSystemGradle},
+		{"go.mod", BuildSystemGo},
+		{filepath.Join("fuzz", "Cargo.toml"), BuildSystemRust},
+	}
+
+	for _, marker := range markers {
+		exists, err := fileExists(filepath.Join(projectDir, marker.file))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return marker.buildSystem, nil
+		}
+	}
+
+	return "", errors.Errorf(
+		`Failed to determine the build system used by the project. Please specify
+the build system using the "--build-system" flag or the "build-system"
+setting in cifuzz.yaml.`)
+}
+
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, errors.WithStack(err)
+}
@@ -0,0 +1,24 @@
+package config
+
+// Engine identifies which fuzzing engine a fuzz test is executed with.
+type Engine int
+
+const (
+	// LIBFUZZER is the default engine for C/C++ fuzz tests, driven via
+	// libFuzzer's in-process fuzzing loop.
+	LIBFUZZER Engine = iota
+	// GO_NATIVE runs fuzz tests written against Go's native `testing.F`
+	// fuzzing support via `go test -fuzz`.
+	GO_NATIVE
+)
+
+func (e Engine) String() string {
+	switch e {
+	case LIBFUZZER:
+		return "libfuzzer"
+	case GO_NATIVE:
+		return "go-native"
+	default:
+		return "unknown"
+	}
+}
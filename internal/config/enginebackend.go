@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Supported values for the "--engine" flag and the "engine" cifuzz.yaml
+// key, selecting which fuzzing engine drives a C/C++ fuzz test. This is
+// independent of the Engine type in engine.go, which only distinguishes
+// libFuzzer from Go's native fuzzing support for integration-test
+// purposes.
+const (
+	EngineLibFuzzer   = "libfuzzer"
+	EngineAFLPlusPlus = "afl++"
+	EngineHonggfuzz   = "honggfuzz"
+	EngineJazzer      = "jazzer"
+)
+
+var supportedEngineBackends = []string{
+	EngineLibFuzzer,
+	EngineAFLPlusPlus,
+	EngineHonggfuzz,
+	EngineJazzer,
+}
+
+// ValidateEngineBackend checks that engine is one of the supported
+// values.
+func ValidateEngineBackend(engine string) error {
+	for _, e := range supportedEngineBackends {
+		if engine == e {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported engine %q, must be one of: %s",
+		engine, strings.Join(supportedEngineBackends, ", "))
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Sanitizer names supported by the "--sanitizer" flag and the
+// "sanitizers" cifuzz.yaml key, mirroring what OSS-Fuzz's `compile`
+// script accepts for SANITIZER.
+const (
+	SanitizerAddress   = "address"
+	SanitizerUndefined = "undefined"
+	SanitizerMemory    = "memory"
+	SanitizerThread    = "thread"
+	SanitizerLeak      = "leak"
+	SanitizerCoverage  = "coverage"
+)
+
+var supportedSanitizers = map[string]bool{
+	SanitizerAddress:   true,
+	SanitizerUndefined: true,
+	SanitizerMemory:    true,
+	SanitizerThread:    true,
+	SanitizerLeak:      true,
+	SanitizerCoverage:  true,
+}
+
+// incompatibleSanitizerPairs lists sanitizers that can't be linked into
+// the same binary because their runtimes conflict.
+var incompatibleSanitizerPairs = [][2]string{
+	{SanitizerAddress, SanitizerMemory},
+	{SanitizerAddress, SanitizerThread},
+	{SanitizerMemory, SanitizerThread},
+	{SanitizerMemory, SanitizerUndefined},
+}
+
+// ValidateSanitizers checks that every entry in sanitizers is one of the
+// supported names and that no incompatible combination was requested.
+func ValidateSanitizers(sanitizers []string) error {
+	seen := map[string]bool{}
+	for _, s := range sanitizers {
+		if !supportedSanitizers[s] {
+			return errors.Errorf("unsupported sanitizer %q, must be one of: %s",
+				s, strings.Join(SupportedSanitizers(), ", "))
+		}
+		seen[s] = true
+	}
+
+	for _, pair := range incompatibleSanitizerPairs {
+		if seen[pair[0]] && seen[pair[1]] {
+			return errors.Errorf("sanitizers %q and %q can't be combined", pair[0], pair[1])
+		}
+	}
+
+	if seen[SanitizerCoverage] && len(sanitizers) > 1 {
+		return errors.New(`the "coverage" sanitizer can't be combined with other sanitizers`)
+	}
+
+	return nil
+}
+
+// SupportedSanitizers returns the list of sanitizer names ValidateSanitizers accepts.
+func SupportedSanitizers() []string {
+	return []string{
+		SanitizerAddress,
+		SanitizerUndefined,
+		SanitizerMemory,
+		SanitizerThread,
+		SanitizerLeak,
+		SanitizerCoverage,
+	}
+}
+
+// FilterJazzerSanitizers splits sanitizers into the subset the Jazzer
+// runner (used for Maven/Gradle) supports and the rest, so that callers
+// can warn about the sanitizers that get dropped.
+func FilterJazzerSanitizers(sanitizers []string) (supported, unsupported []string) {
+	for _, s := range sanitizers {
+		if s == SanitizerAddress || s == SanitizerUndefined {
+			supported = append(supported, s)
+		} else {
+			unsupported = append(unsupported, s)
+		}
+	}
+	return supported, unsupported
+}
@@ -0,0 +1,125 @@
+// Package container runs a cifuzz invocation inside a Docker (or Podman)
+// container derived from an OSS-Fuzz-style base-builder image, so that
+// "cifuzz run --container" gets the same reproducible Linux/ASan
+// environment regardless of the host OS.
+package container
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+)
+
+// imageTag pins the base-builder image tag used for --container runs to
+// this cifuzz release, so that a build done today and a build done with
+// the same cifuzz version next year use the identical environment.
+const imageTag = "v0.1.0"
+
+// defaultImages maps each build system to the base-builder image variant
+// that has its toolchain (and cifuzz itself) preinstalled.
+var defaultImages = map[string]string{
+	config.BuildSystemCMake:  "ghcr.io/code-intelligence/cifuzz-base-builder-cpp:" + imageTag,
+	config.BuildSystemBazel:  "ghcr.io/code-intelligence/cifuzz-base-builder-cpp:" + imageTag,
+	config.BuildSystemOther:  "ghcr.io/code-intelligence/cifuzz-base-builder-cpp:" + imageTag,
+	config.BuildSystemMaven:  "ghcr.io/code-intelligence/cifuzz-base-builder-java:" + imageTag,
+	config.BuildSystemGradle: "ghcr.io/code-intelligence/cifuzz-base-builder-java:" + imageTag,
+	config.BuildSystemGo:     "ghcr.io/code-intelligence/cifuzz-base-builder-go:" + imageTag,
+}
+
+// DefaultImage returns the base-builder image to use for buildSystem
+// when the user didn't override it with --container-image.
+func DefaultImage(buildSystem string) (string, error) {
+	image, ok := defaultImages[buildSystem]
+	if !ok {
+		return "", errors.Errorf("no container image is available for build system %q", buildSystem)
+	}
+	return image, nil
+}
+
+// Options configures a single containerized cifuzz invocation.
+type Options struct {
+	// Image is the base-builder image to run Command in.
+	Image string
+	// Command is the cifuzz command line to execute inside the
+	// container, equivalent to the host invocation that enabled
+	// --container in the first place, but without that flag (to avoid
+	// recursing back into a container).
+	Command []string
+	// ProjectDir, TempDir, and CacheDir are bind-mounted read-write at
+	// the same path inside the container as on the host, so that paths
+	// cifuzz itself resolves (e.g. build directories it prints or
+	// stores in cifuzz.yaml) stay meaningful on both sides of the
+	// mount.
+	ProjectDir string
+	TempDir    string
+	CacheDir   string
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+func (opts *Options) Validate() error {
+	if opts.Image == "" {
+		return errors.New("Image is not set")
+	}
+	if len(opts.Command) == 0 {
+		return errors.New("Command is not set")
+	}
+	if opts.ProjectDir == "" {
+		return errors.New("ProjectDir is not set")
+	}
+	return nil
+}
+
+type Runner struct {
+	*Options
+	binary string
+}
+
+// NewRunner detects whether docker or podman is available (preferring
+// docker) and returns a Runner which uses it.
+func NewRunner(opts *Options) (*Runner, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	runtime, err := dependencies.CheckAny([]dependencies.Key{dependencies.DOCKER, dependencies.PODMAN})
+	if err != nil {
+		return nil, errors.Wrap(err, `"--container" requires docker or podman`)
+	}
+
+	return &Runner{Options: opts, binary: runtime.String()}, nil
+}
+
+// Run executes Command inside a fresh container of Image, with
+// ProjectDir, TempDir, and CacheDir bind-mounted read-write.
+func (r *Runner) Run(ctx context.Context) error {
+	args := []string{
+		"run", "--rm",
+		"-v", r.ProjectDir + ":" + r.ProjectDir,
+		"-w", r.ProjectDir,
+	}
+	if r.TempDir != "" {
+		args = append(args, "-v", r.TempDir+":"+r.TempDir)
+	}
+	if r.CacheDir != "" {
+		args = append(args, "-v", r.CacheDir+":"+r.CacheDir)
+	}
+	args = append(args, r.Image)
+	args = append(args, r.Command...)
+
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
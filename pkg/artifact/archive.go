@@ -0,0 +1,212 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ContentManifestName is the name of the per-bundle manifest listing the
+// SHA-256 hash of every file entry in the archive, which Verify uses to
+// detect content drift.
+const ContentManifestName = "content.sha256"
+
+// sortedPaths returns the archive paths of fileMap in lexical order, so
+// that WriteArchive and WriteContentManifest don't depend on map
+// iteration order.
+func sortedPaths(fileMap FileMap) []string {
+	paths := make([]string, 0, len(fileMap))
+	for p := range fileMap {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// archiveMode returns the fixed file mode WriteArchive uses for an entry:
+// the "bin/" directory and "manifest.jar" files are made executable,
+// everything else is plain data.
+func archiveMode(archivePath string) int64 {
+	if strings.HasPrefix(archivePath, "bin/") || path.Base(archivePath) == "manifest.jar" {
+		return 0755
+	}
+	return 0644
+}
+
+// WriteArchive writes fileMap out as a gzip-compressed tar archive.
+// Entries are written in sorted archive-path order with their mtime
+// pinned to sourceDateEpoch, their uid and gid zeroed out, and a fixed
+// mode per archiveMode, so that archiving the same FileMap at the same
+// sourceDateEpoch always produces a bit-for-bit identical archive,
+// regardless of the machine, user, or wall-clock time it was built on.
+// Pass ParseSourceDateEpoch("") for the conventional all-zero timestamp.
+func WriteArchive(w io.Writer, fileMap FileMap, sourceDateEpoch time.Time) error {
+	gzw := gzip.NewWriter(w)
+	// gzip embeds a modification time in its header unless it's zeroed.
+	gzw.ModTime = sourceDateEpoch
+	tw := tar.NewWriter(gzw)
+
+	for _, archivePath := range sortedPaths(fileMap) {
+		err := writeEntry(tw, archivePath, fileMap[archivePath], sourceDateEpoch)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(gzw.Close())
+}
+
+func writeEntry(tw *tar.Writer, archivePath, sourcePath string, modTime time.Time) error {
+	info, err := os.Lstat(sourcePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if info.IsDir() {
+		header := &tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     archivePath + "/",
+			Mode:     0755,
+			ModTime:  modTime,
+		}
+		return errors.WithStack(tw.WriteHeader(header))
+	}
+
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     archivePath,
+		Size:     int64(len(content)),
+		Mode:     archiveMode(archivePath),
+		ModTime:  modTime,
+	}
+	err = tw.WriteHeader(header)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = tw.Write(content)
+	return errors.WithStack(err)
+}
+
+// ParseSourceDateEpoch parses a SOURCE_DATE_EPOCH value - a decimal count
+// of seconds since the Unix epoch, per
+// https://reproducible-builds.org/specs/source-date-epoch/ - into the
+// time.Time WriteArchive pins entry mtimes to. An empty string (no
+// "--source-date-epoch" flag and no SOURCE_DATE_EPOCH in the
+// environment) yields the Unix epoch itself, the timestamp bundles used
+// unconditionally before this flag existed.
+func ParseSourceDateEpoch(value string) (time.Time, error) {
+	if value == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid SOURCE_DATE_EPOCH value %q", value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// HashEntries computes the SHA-256 hash of every file (not directory)
+// entry in fileMap, keyed by archive path.
+func HashEntries(fileMap FileMap) (map[string]string, error) {
+	hashes := make(map[string]string, len(fileMap))
+	for _, archivePath := range sortedPaths(fileMap) {
+		info, err := os.Lstat(fileMap[archivePath])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		content, err := os.ReadFile(fileMap[archivePath])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sum := sha256.Sum256(content)
+		hashes[archivePath] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
+
+// WriteContentManifest writes the ContentManifestName manifest for
+// fileMap: one "<sha256>  <archive path>" line per file entry, sorted by
+// archive path, in the same format as sha256sum(1).
+func WriteContentManifest(w io.Writer, fileMap FileMap) error {
+	hashes, err := HashEntries(fileMap)
+	if err != nil {
+		return err
+	}
+
+	for _, archivePath := range sortedPaths(fileMap) {
+		hash, ok := hashes[archivePath]
+		if !ok {
+			continue
+		}
+		_, err := fmt.Fprintf(w, "%s  %s\n", hash, archivePath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// Verify re-reads every file an extracted bundle's content.sha256
+// manifest lists, hashes it, and returns an error describing the first
+// entry whose content no longer matches the hash recorded when the
+// bundle was written - i.e. the bundle has drifted since then.
+func Verify(bundleDir string) error {
+	manifest, err := os.ReadFile(filepath.Join(bundleDir, ContentManifestName))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("invalid content manifest line: %q", line)
+		}
+		wantHash, archivePath := parts[0], parts[1]
+
+		content, err := os.ReadFile(filepath.Join(bundleDir, archivePath))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		sum := sha256.Sum256(content)
+		gotHash := hex.EncodeToString(sum[:])
+		if gotHash != wantHash {
+			return errors.Errorf("bundle content drift detected in %s: expected sha256 %s, got %s",
+				archivePath, wantHash, gotHash)
+		}
+	}
+
+	return errors.WithStack(scanner.Err())
+}
@@ -0,0 +1,77 @@
+package artifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteArchiveReproducible verifies that writing the same FileMap
+// twice at the same --source-date-epoch produces byte-identical
+// archives, the property chunk5-6 added WriteArchive's sourceDateEpoch
+// parameter for. The cifuzz CLI's own "bundle" command doesn't exist in
+// this checkout to invoke end-to-end, so this exercises the same
+// property directly against the library it would call.
+func TestWriteArchiveReproducible(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "fuzzer")
+	require.NoError(t, os.WriteFile(filePath, []byte("fuzzer contents"), 0o644))
+
+	fileMap := FileMap{"bin/fuzzer": filePath}
+	epoch, err := ParseSourceDateEpoch("1700000000")
+	require.NoError(t, err)
+
+	var first, second bytes.Buffer
+	require.NoError(t, WriteArchive(&first, fileMap, epoch))
+	require.NoError(t, WriteArchive(&second, fileMap, epoch))
+
+	assert.Equal(t, sha256Sum(first.Bytes()), sha256Sum(second.Bytes()))
+}
+
+func sha256Sum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// TestWriteProvenance verifies the emitted document is valid in-toto
+// Statement/SLSA Provenance JSON with one subject per file and the
+// requested branch/commit/docker-image metadata.
+func TestWriteProvenance(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "fuzzer")
+	require.NoError(t, os.WriteFile(filePath, []byte("fuzzer contents"), 0o644))
+	fileMap := FileMap{"bin/fuzzer": filePath}
+
+	var buf bytes.Buffer
+	err := WriteProvenance(&buf, fileMap, ProvenanceOptions{
+		Branch:      "main",
+		Commit:      "abc123",
+		DockerImage: "my-image",
+		Toolchain:   map[string]string{"cifuzz": "1.2.3"},
+	})
+	require.NoError(t, err)
+
+	var statement Provenance
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &statement))
+
+	assert.Equal(t, inTotoStatementType, statement.Type)
+	assert.Equal(t, slsaPredicateType, statement.PredicateType)
+	require.Len(t, statement.Subject, 1)
+	assert.Equal(t, "bin/fuzzer", statement.Subject[0].Name)
+
+	wantHash, err := HashEntries(fileMap)
+	require.NoError(t, err)
+	assert.Equal(t, wantHash["bin/fuzzer"], statement.Subject[0].Digest["sha256"])
+
+	assert.Equal(t, "main", statement.Predicate.Metadata.Branch)
+	assert.Equal(t, "abc123", statement.Predicate.Metadata.Commit)
+	assert.Equal(t, "my-image", statement.Predicate.Metadata.DockerImage)
+	assert.Equal(t, "1.2.3", statement.Predicate.Metadata.Toolchain["cifuzz"])
+}
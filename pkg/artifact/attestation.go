@@ -0,0 +1,107 @@
+package artifact
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// The in-toto Statement layer and SLSA Provenance predicate this package
+// emits. See https://in-toto.io/Statement/v0.1 and
+// https://slsa.dev/provenance/v0.2.
+const (
+	inTotoStatementType = "https://in-toto.io/Statement/v0.1"
+	slsaPredicateType   = "https://slsa.dev/provenance/v0.2"
+	slsaBuilderID       = "https://code-intelligence.com/cifuzz/bundle"
+)
+
+// Provenance is an in-toto Statement whose predicate is a SLSA
+// provenance document: one subject entry (with its SHA-256 digest) per
+// file a bundle archive contains, plus the build facts recorded in its
+// Predicate.Metadata.
+type Provenance struct {
+	Type          string              `json:"_type"`
+	PredicateType string              `json:"predicateType"`
+	Subject       []ProvenanceSubject `json:"subject"`
+	Predicate     ProvenancePredicate `json:"predicate"`
+}
+
+// ProvenanceSubject identifies one archive entry and its content hash.
+type ProvenanceSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ProvenancePredicate is the SLSA predicate body.
+type ProvenancePredicate struct {
+	Builder  ProvenanceBuilder  `json:"builder"`
+	Metadata ProvenanceMetadata `json:"metadata"`
+}
+
+// ProvenanceBuilder identifies what produced the bundle.
+type ProvenanceBuilder struct {
+	ID string `json:"id"`
+}
+
+// ProvenanceMetadata records the cifuzz-specific build facts that don't
+// have a standard SLSA field: the code revision and Docker image the
+// bundle's --branch/--commit/--docker-image flags captured, and the
+// toolchain versions that produced its contents.
+type ProvenanceMetadata struct {
+	Branch      string            `json:"branch,omitempty"`
+	Commit      string            `json:"commit,omitempty"`
+	DockerImage string            `json:"dockerImage,omitempty"`
+	Toolchain   map[string]string `json:"toolchain,omitempty"`
+}
+
+// ProvenanceOptions configures WriteProvenance.
+type ProvenanceOptions struct {
+	Branch      string
+	Commit      string
+	DockerImage string
+	// Toolchain lists the name/version pairs of the tools (compiler,
+	// build system, cifuzz itself) that produced the bundle's contents,
+	// e.g. {"cifuzz": "1.2.3", "clang": "16.0.0"}.
+	Toolchain map[string]string
+}
+
+// WriteProvenance writes an in-toto/SLSA provenance statement for
+// fileMap's contents to w: the "--attest" counterpart to
+// WriteArchive/WriteContentManifest, meant to be written to
+// "<bundle>.intoto.jsonl" alongside the bundle archive itself.
+func WriteProvenance(w io.Writer, fileMap FileMap, opts ProvenanceOptions) error {
+	hashes, err := HashEntries(fileMap)
+	if err != nil {
+		return err
+	}
+
+	subjects := make([]ProvenanceSubject, 0, len(hashes))
+	for _, archivePath := range sortedPaths(fileMap) {
+		hash, ok := hashes[archivePath]
+		if !ok {
+			continue
+		}
+		subjects = append(subjects, ProvenanceSubject{
+			Name:   archivePath,
+			Digest: map[string]string{"sha256": hash},
+		})
+	}
+
+	statement := Provenance{
+		Type:          inTotoStatementType,
+		PredicateType: slsaPredicateType,
+		Subject:       subjects,
+		Predicate: ProvenancePredicate{
+			Builder: ProvenanceBuilder{ID: slsaBuilderID},
+			Metadata: ProvenanceMetadata{
+				Branch:      opts.Branch,
+				Commit:      opts.Commit,
+				DockerImage: opts.DockerImage,
+				Toolchain:   opts.Toolchain,
+			},
+		},
+	}
+
+	return errors.WithStack(json.NewEncoder(w).Encode(statement))
+}
@@ -0,0 +1,5 @@
+package artifact
+
+// FileMap maps a bundle-relative archive path to the absolute path of the
+// file or directory on disk that should be placed there.
+type FileMap map[string]string
@@ -0,0 +1,190 @@
+// Package artifact collects the pieces of a cifuzz bundle: the fuzzer
+// metadata and file layout the bundler writes into a bundle archive, plus
+// helpers that turn a fuzzing run's findings into formats third-party
+// tooling consumes directly, such as SARIF.
+//
+// NOTE: this tree is missing the rest of the bundler's artifact types
+// (Fuzzer, EngineOptions, the bundle.yaml manifest writer, ...) that
+// internal/bundler builds on, so this file only contains the
+// self-contained SARIF piece of chunk2-3. Wiring it into
+// assembleArtifacts - generating findings.sarif, adding it to FileMap,
+// and referencing it from the bundle manifest - is left for when that
+// code exists to wire it into.
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// sarifVersion is the SARIF spec version this file emits.
+const sarifVersion = "2.1.0"
+
+const sarifSchema = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+
+// SARIFLog is the top-level SARIF log object.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun groups the results of a single analysis tool run.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name  string      `json:"name"`
+	Rules []SARIFRule `json:"rules,omitempty"`
+}
+
+// SARIFRule describes one finding category (sanitizer kind) results can
+// reference by ruleId.
+type SARIFRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SARIFResult is a single finding, translated to SARIF.
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []SARIFLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// GenerateSARIF turns findings into a SARIF 2.1.0 log, suitable for
+// writing out as a bundle's "findings.sarif" sidecar. Locations are made
+// relative to projectDir so the resulting file is portable across
+// machines; findings whose InputFile isn't inside projectDir are
+// reported without a location.
+func GenerateSARIF(projectDir string, findings []*report.Finding) ([]byte, error) {
+	rules := map[string]bool{}
+	results := make([]SARIFResult, 0, len(findings))
+
+	for _, finding := range findings {
+		ruleID := finding.Type
+		if ruleID == "" {
+			ruleID = "unknown"
+		}
+		rules[ruleID] = true
+
+		result := SARIFResult{
+			RuleID:  ruleID,
+			Level:   "error",
+			Message: SARIFMessage{Text: findingMessage(finding)},
+			PartialFingerprints: map[string]string{
+				"stackHash/v1": stackHash(finding),
+			},
+		}
+
+		if location, ok := findingLocation(projectDir, finding); ok {
+			result.Locations = []SARIFLocation{location}
+		}
+
+		results = append(results, result)
+	}
+
+	log := SARIFLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SARIFRun{
+			{
+				Tool: SARIFTool{
+					Driver: SARIFDriver{
+						Name:  "cifuzz",
+						Rules: sarifRules(rules),
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	out, err := json.MarshalIndent(&log, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+func sarifRules(seen map[string]bool) []SARIFRule {
+	rules := make([]SARIFRule, 0, len(seen))
+	for id := range seen {
+		rules = append(rules, SARIFRule{ID: id, Name: id})
+	}
+	return rules
+}
+
+func findingMessage(finding *report.Finding) string {
+	if finding.Details != "" {
+		return finding.Details
+	}
+	return finding.Name
+}
+
+// findingLocation points at the finding's crashing input file, when
+// InputFile is set and lies inside projectDir.
+func findingLocation(projectDir string, finding *report.Finding) (SARIFLocation, bool) {
+	if finding.InputFile == "" {
+		return SARIFLocation{}, false
+	}
+
+	rel, err := filepath.Rel(projectDir, finding.InputFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return SARIFLocation{}, false
+	}
+
+	return SARIFLocation{
+		PhysicalLocation: SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{
+				URI: filepath.ToSlash(rel),
+			},
+		},
+	}, true
+}
+
+// stackHash derives a stable fingerprint for a finding from its stack
+// trace (falling back to its input data), so that the same underlying
+// crash reported across multiple bundles dedupes in SARIF consumers like
+// GitHub Code Scanning.
+func stackHash(finding *report.Finding) string {
+	h := sha256.New()
+	if len(finding.Logs) > 0 {
+		h.Write([]byte(strings.Join(finding.Logs, "\n")))
+	} else {
+		h.Write(finding.InputData)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
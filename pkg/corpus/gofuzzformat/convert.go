@@ -0,0 +1,61 @@
+package gofuzzformat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ConvertSeedCorpusDir reads every `go test fuzz v1` file in srcDir
+// (typically `testdata/fuzz/FuzzTarget`) and writes the raw bytes a
+// libFuzzer-style target would receive into dstDir, one file per entry
+// named after the SHA-256 hash of its contents. Files in srcDir that
+// aren't in the go fuzz corpus format are skipped, since
+// `testdata/fuzz/FuzzTarget` directories may be freely mixed with
+// raw seeds added by earlier cifuzz runs.
+func ConvertSeedCorpusDir(srcDir, dstDir string) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.MkdirAll(dstDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		srcPath := filepath.Join(srcDir, entry.Name())
+		content, err := os.ReadFile(srcPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		values, err := Decode(content)
+		if err != nil {
+			// Not a go fuzz corpus file (or not one we understand);
+			// leave it for the caller to handle as a raw seed.
+			continue
+		}
+
+		raw, err := Bytes(values)
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.Sum256(raw)
+		dstPath := filepath.Join(dstDir, hex.EncodeToString(hash[:]))
+		err = os.WriteFile(dstPath, raw, 0o644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package gofuzzformat implements the `go test fuzz v1` corpus file
+// format used by Go's native `testing.F` fuzzing engine, so that seed and
+// generated corpora written in this format can be shared with
+// cifuzz's libFuzzer-based runners, which only understand raw byte
+// inputs.
+package gofuzzformat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// header is the first line of every `go test fuzz v1` corpus file.
+const header = "go test fuzz v1"
+
+// Value is a single typed literal encoded in a corpus file, e.g. the
+// arguments passed to f.Fuzz(data []byte) or f.Fuzz(s string, n int).
+type Value struct {
+	// Type is one of "[]byte", "string", "int", "int8", "int16", "int32",
+	// "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32",
+	// "float64", "bool", or "rune".
+	Type string
+	// Raw is the Go syntax representation of the literal, e.g. `"hello"`
+	// for a string or `12345` for an int. For []byte it's the quoted Go
+	// string literal inside the `[]byte(...)` call.
+	Raw string
+}
+
+// supportedTypes lists every literal type the `go test fuzz v1` format
+// may contain, used to reject unknown types with a clear error.
+var supportedTypes = map[string]bool{
+	"[]byte": true, "string": true, "bool": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// Encode serializes values into the `go test fuzz v1` text format, one
+// literal per line following the header.
+func Encode(values []Value) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header + "\n")
+	for _, v := range values {
+		if !supportedTypes[v.Type] {
+			return nil, errors.Errorf("unsupported go fuzz corpus type %q", v.Type)
+		}
+		fmt.Fprintf(&buf, "%s(%s)\n", v.Type, v.Raw)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeBytes is a convenience wrapper around Encode for the common case
+// of a single []byte argument, which is what libFuzzer-style fuzz
+// targets expect.
+func EncodeBytes(data []byte) ([]byte, error) {
+	return Encode([]Value{{Type: "[]byte", Raw: strconv.Quote(string(data))}})
+}
+
+// Decode parses a `go test fuzz v1` corpus file into its typed values.
+func Decode(data []byte) ([]Value, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		return nil, errors.New("empty go fuzz corpus file")
+	}
+	if strings.TrimSpace(scanner.Text()) != header {
+		return nil, errors.Errorf("unexpected go fuzz corpus header: %q", scanner.Text())
+	}
+
+	var values []Value
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		value, err := decodeLiteral(line)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return values, nil
+}
+
+// decodeLiteral parses a single line, e.g. `[]byte("12345")` or
+// `float64(1.5)`, into a Value.
+func decodeLiteral(line string) (Value, error) {
+	open := strings.Index(line, "(")
+	if open == -1 || !strings.HasSuffix(line, ")") {
+		return Value{}, errors.Errorf("malformed go fuzz corpus literal: %q", line)
+	}
+	typ := line[:open]
+	raw := line[open+1 : len(line)-1]
+
+	if !supportedTypes[typ] {
+		return Value{}, errors.Errorf("unsupported go fuzz corpus type %q in literal %q", typ, line)
+	}
+
+	return Value{Type: typ, Raw: raw}, nil
+}
+
+// Bytes returns the raw bytes that a libFuzzer-style fuzz target would
+// receive for this set of values: for a single []byte or string literal,
+// the decoded content; for multiple literals, their concatenation in
+// argument order. This is a best-effort bridge since libFuzzer only ever
+// passes a single byte slice, while `f.Fuzz` may take several typed
+// arguments.
+func Bytes(values []Value) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, v := range values {
+		raw, err := rawBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(raw)
+	}
+	return buf.Bytes(), nil
+}
+
+func rawBytes(v Value) ([]byte, error) {
+	switch v.Type {
+	case "[]byte", "string":
+		unquoted, err := strconv.Unquote(v.Raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s literal %q", v.Type, v.Raw)
+		}
+		return []byte(unquoted), nil
+	case "rune":
+		unquoted, _, _, err := strconv.UnquoteChar(strings.Trim(v.Raw, "'"), '\'')
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rune literal %q", v.Raw)
+		}
+		return []byte(string(unquoted)), nil
+	case "bool", "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return []byte(v.Raw), nil
+	default:
+		return nil, errors.Errorf("unsupported go fuzz corpus type %q", v.Type)
+	}
+}
@@ -0,0 +1,54 @@
+package gofuzzformat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundtrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		value Value
+	}{
+		{"bytes", Value{Type: "[]byte", Raw: `"12345"`}},
+		{"string", Value{Type: "string", Raw: `"hello"`}},
+		{"int", Value{Type: "int", Raw: "-3"}},
+		{"float64", Value{Type: "float64", Raw: "1.5"}},
+		{"bool", Value{Type: "bool", Raw: "true"}},
+		{"rune", Value{Type: "rune", Raw: "'a'"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			encoded, err := Encode([]Value{c.value})
+			require.NoError(t, err)
+
+			decoded, err := Decode(encoded)
+			require.NoError(t, err)
+			require.Len(t, decoded, 1)
+			assert.Equal(t, c.value, decoded[0])
+		})
+	}
+}
+
+func TestDecodeRejectsUnknownType(t *testing.T) {
+	_, err := Decode([]byte("go test fuzz v1\ncomplex128(1+2i)\n"))
+	assert.Error(t, err)
+}
+
+func TestDecodeRejectsMissingHeader(t *testing.T) {
+	_, err := Decode([]byte(`[]byte("12345")` + "\n"))
+	assert.Error(t, err)
+}
+
+func TestBytesConcatenatesArguments(t *testing.T) {
+	values := []Value{
+		{Type: "[]byte", Raw: `"abc"`},
+		{Type: "string", Raw: `"def"`},
+	}
+	raw, err := Bytes(values)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("abcdef"), raw)
+}
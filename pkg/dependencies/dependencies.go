@@ -0,0 +1,91 @@
+// Package dependencies checks that the external tools cifuzz shells out to
+// (compilers, build systems, coverage tooling, ...) are installed and
+// discoverable on PATH before a command attempts to use them, so that
+// failures show up as one clear message instead of a confusing error from
+// deep inside a subprocess invocation.
+package dependencies
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Key identifies an external tool cifuzz depends on.
+type Key int
+
+const (
+	CLANG Key = iota
+	LLVM_SYMBOLIZER
+	LLVM_PROFDATA
+	LLVM_COV
+	CMAKE
+	JAVA
+	MAVEN
+	GRADLE
+	BAZEL
+	GO
+	AFLPLUSPLUS
+	HONGGFUZZ
+	DOCKER
+	PODMAN
+	PYTHON
+	CARGO
+)
+
+// command is the name of the binary Check looks up on PATH for a given Key.
+var command = map[Key]string{
+	CLANG:           "clang",
+	LLVM_SYMBOLIZER: "llvm-symbolizer",
+	LLVM_PROFDATA:   "llvm-profdata",
+	LLVM_COV:        "llvm-cov",
+	CMAKE:           "cmake",
+	JAVA:            "java",
+	MAVEN:           "mvn",
+	GRADLE:          "gradle",
+	BAZEL:           "bazel",
+	GO:              "go",
+	AFLPLUSPLUS:     "afl-fuzz",
+	HONGGFUZZ:       "honggfuzz",
+	DOCKER:          "docker",
+	PODMAN:          "podman",
+	PYTHON:          "python3",
+	CARGO:           "cargo",
+}
+
+// String returns the name of the binary this Key checks for.
+func (k Key) String() string {
+	return command[k]
+}
+
+// Check verifies that every dependency in keys is installed and found on
+// PATH, returning an error naming the first one that isn't.
+func Check(keys []Key) error {
+	for _, key := range keys {
+		name := key.String()
+		_, err := exec.LookPath(name)
+		if err != nil {
+			return errors.Errorf("%s is required but was not found in PATH", name)
+		}
+	}
+	return nil
+}
+
+// CheckAny verifies that at least one dependency in keys is installed
+// and found on PATH, returning the first one found. It's used where
+// keys are alternatives rather than all required, e.g. DOCKER or
+// PODMAN for "--container".
+func CheckAny(keys []Key) (Key, error) {
+	for _, key := range keys {
+		if _, err := exec.LookPath(key.String()); err == nil {
+			return key, nil
+		}
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+	}
+	return 0, errors.Errorf("none of the following were found in PATH: %s", strings.Join(names, ", "))
+}
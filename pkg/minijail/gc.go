@@ -0,0 +1,192 @@
+package minijail
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// ownerPIDFile, written into every chroot directory New creates, records
+// the PID of the cifuzz process that owns it, so that GC can tell a
+// chroot left behind by a process that no longer exists apart from one
+// that's still in active use.
+const ownerPIDFile = "cifuzz-owner-pid"
+
+func writeOwnerPIDFile(chrootDir string) error {
+	err := os.WriteFile(filepath.Join(chrootDir, ownerPIDFile), []byte(strconv.Itoa(os.Getpid())), 0o600)
+	return errors.WithStack(err)
+}
+
+// chroots tracks every chroot directory created by a Minijail in this
+// process that hasn't been cleaned up yet, so the signal handler below
+// can remove them even if the Runner's own deferred Cleanup never runs
+// (e.g. the process is killed before reaching it). It can't help against
+// SIGKILL, which no handler can intercept - that's what the "minijail gc"
+// subcommand is for.
+var (
+	chrootsMu sync.Mutex
+	chroots   = map[string]struct{}{}
+)
+
+func registerChroot(dir string) {
+	chrootsMu.Lock()
+	defer chrootsMu.Unlock()
+	chroots[dir] = struct{}{}
+}
+
+func unregisterChroot(dir string) {
+	chrootsMu.Lock()
+	defer chrootsMu.Unlock()
+	delete(chroots, dir)
+}
+
+func init() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		chrootsMu.Lock()
+		dirs := make([]string, 0, len(chroots))
+		for dir := range chroots {
+			dirs = append(dirs, dir)
+		}
+		chrootsMu.Unlock()
+
+		for _, dir := range dirs {
+			fileutil.Cleanup(dir)
+		}
+
+		// Re-raise the signal against ourselves with the default handler
+		// restored, so the process still exits the way it would have
+		// without this handler (e.g. with the signal's conventional exit
+		// code), instead of silently swallowing it.
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		proc, err := os.FindProcess(os.Getpid())
+		if err == nil {
+			_ = proc.Signal(sig)
+		}
+	}()
+}
+
+// GC removes chroot directories under os.TempDir() matching
+// "minijail-chroot-*" that are older than maxAge and whose owning process
+// (recorded in ownerPIDFile when the chroot was created) no longer
+// exists. Chroots without an ownerPIDFile, or whose owning process is
+// still alive, are left alone.
+func GC(maxAge time.Duration) error {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "minijail-chroot-") {
+			continue
+		}
+		dir := filepath.Join(os.TempDir(), entry.Name())
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if now.Sub(info.ModTime()) < maxAge {
+			continue
+		}
+
+		if processAlive(dir) {
+			continue
+		}
+
+		err = unmountStaleMounts(dir)
+		if err != nil {
+			log.Warnf("failed to unmount stale mounts under %s: %s", dir, err)
+			continue
+		}
+
+		log.Debugf("removing stale chroot %s", dir)
+		fileutil.Cleanup(dir)
+	}
+
+	return nil
+}
+
+// processAlive reports whether dir's ownerPIDFile names a PID that's
+// still running. A missing ownerPIDFile (e.g. a chroot from before this
+// file was introduced) is treated as "still in use" to be conservative.
+func processAlive(dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, ownerPIDFile))
+	if err != nil {
+		return true
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return true
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Unix, os.FindProcess always succeeds; signal 0 does no harm but
+	// still fails if the PID doesn't exist (or has been reused by a
+	// process we can't signal), which is what we're after here.
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// unmountStaleMounts unmounts anything still mounted under dir according
+// to /proc/self/mountinfo before it's removed, in case one of the
+// bind-mounts we set up ever became a real mount (rather than the
+// no-op rename minijail usually does for them) - RemoveAll on a directory
+// with something still mounted under it would otherwise silently delete
+// the mount point's contents instead of the (possibly externally owned)
+// mounted filesystem's.
+func unmountStaleMounts(dir string) error {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		// Nothing we can check on non-Linux systems; fall through to
+		// RemoveAll as before.
+		return nil
+	}
+	defer file.Close()
+
+	var mountPoints []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mountPoint := fields[4]
+		if mountPoint == dir || strings.HasPrefix(mountPoint, dir+"/") {
+			mountPoints = append(mountPoints, mountPoint)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Unmount the deepest paths first so a mount nested under another
+	// stale mount doesn't get orphaned. We shell out to umount(8) rather
+	// than calling syscall.Unmount directly since that syscall (like the
+	// mount flags in minijail.go) isn't available on every platform this package
+	// is built for, even though GC itself is only ever useful on Linux.
+	for i := len(mountPoints) - 1; i >= 0; i-- {
+		err := exec.Command("umount", mountPoints[i]).Run()
+		if err != nil {
+			return errors.Wrapf(err, "failed to unmount %s", mountPoints[i])
+		}
+	}
+	return nil
+}
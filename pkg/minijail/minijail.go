@@ -1,3 +1,7 @@
+// Package minijail sandboxes fuzzing and replay runs using minijail0. It
+// registers itself with pkg/sandbox as the "minijail" backend; callers
+// should go through sandbox.New rather than calling New directly unless
+// they specifically need a minijail sandbox and nothing else will do.
 package minijail
 
 import (
@@ -11,10 +15,17 @@ import (
 
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/pkg/sandbox"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
+func init() {
+	sandbox.Register(sandbox.BackendMinijail, func(opts *sandbox.Options) (sandbox.Runner, error) {
+		return New(opts)
+	})
+}
+
 const (
 	// Mount flags as defined in golang.org/x/sys/unix. We're not using
 	// that package because it's not available on macOS.
@@ -26,24 +37,43 @@ const (
 	MS_STRICTATIME = 0x1000000
 )
 
-type WritableOption int
-
 const (
-	ReadOnly WritableOption = iota
-	ReadWrite
+	// Resource numbers as defined in golang.org/x/sys/unix (RLIMIT_*),
+	// not used directly for the same reason as the mount flags above.
+	RLIMIT_CPU    = 0
+	RLIMIT_FSIZE  = 1
+	RLIMIT_CORE   = 4
+	RLIMIT_NPROC  = 6
+	RLIMIT_NOFILE = 7
+	RLIMIT_AS     = 9
 )
 
-type Binding struct {
-	Source   string
-	Target   string
-	Writable WritableOption
+// rlimitConfigLines renders limits into minijail's "rlimit=<resource>,
+// <cur>,<max>" config directives, skipping any field left at its zero
+// value.
+func rlimitConfigLines(limits *sandbox.Limits) []string {
+	var lines []string
+	add := func(resource int, value uint64) {
+		if value == 0 {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("rlimit=%d,%d,%d", resource, value, value))
+	}
+	add(RLIMIT_AS, limits.AddressSpaceBytes)
+	add(RLIMIT_FSIZE, limits.FileSizeBytes)
+	add(RLIMIT_CPU, limits.CPUSeconds)
+	add(RLIMIT_NOFILE, limits.NoFile)
+	add(RLIMIT_NPROC, limits.NProc)
+	add(RLIMIT_CORE, limits.CoreBytes)
+	return lines
 }
 
-func (b *Binding) String() string {
+// bindingString renders b in minijail's "bind-mount=" syntax.
+func bindingString(b *sandbox.Binding) string {
 	if b.Target == "" {
 		b.Target = b.Source
 	}
-	if b.Writable == ReadWrite {
+	if b.Writable == sandbox.ReadWrite {
 		return fmt.Sprintf("%s,%s,1", b.Source, b.Target)
 	}
 	// Don't use a short form if the source or target contain a comma,
@@ -102,29 +132,28 @@ var minijailConfigLines = []string{
 	"logging=stderr",
 }
 
-var defaultBindings = []*Binding{
+var defaultBindings = []*sandbox.Binding{
 	// We allow access to /dev/null and /dev/urandom because AFL needs
 	// access to them and some fuzz targets might as well (for example
 	// our lighttpd example fuzz target).
 	// They have to be mounted read-write, else minijail fails with
 	// libminijail[1]: cannot bind-remount: [...] Operation not permitted
-	{Source: "/dev/null", Writable: ReadWrite},
-	{Source: "/dev/urandom", Writable: ReadWrite},
-}
-
-type Options struct {
-	Args      []string
-	Bindings  []*Binding
-	OutputDir string
+	{Source: "/dev/null", Writable: sandbox.ReadWrite},
+	{Source: "/dev/urandom", Writable: sandbox.ReadWrite},
 }
 
-type minijail struct {
-	*Options
-	Args      []string
+// Minijail is a sandboxed run set up via minijail0. It implements
+// sandbox.Runner.
+type Minijail struct {
+	*sandbox.Options
+	args      []string
 	chrootDir string
 }
 
-func NewMinijail(opts *Options) (*minijail, error) {
+// New creates a Minijail for opts. Most callers should go through
+// sandbox.New instead, which also lets the run be built with nsjail or
+// no sandboxing at all depending on Options.Backend/CIFUZZ_SANDBOX.
+func New(opts *sandbox.Options) (*Minijail, error) {
 	// Evaluate symlinks in the executable path
 	path, err := filepath.EvalSymlinks(opts.Args[0])
 	if err != nil {
@@ -140,6 +169,20 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	if err != nil {
 		return nil, err
 	}
+	// os.MkdirTemp already creates directories 0700 on most systems, but
+	// that's not guaranteed by its documentation, and the chroot holds
+	// bind-mount stubs for every file the sandboxed process can reach -
+	// make the permission explicit instead of relying on the platform
+	// default.
+	err = os.Chmod(chrootDir, 0o700)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = writeOwnerPIDFile(chrootDir)
+	if err != nil {
+		return nil, err
+	}
+	registerChroot(chrootDir)
 
 	// Create /tmp, /proc directories.
 	for _, dir := range []string{"/proc", "/tmp"} {
@@ -187,10 +230,32 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	// Change root filesystem to the chroot directory. See pivot_root(2).
 	minijailArgs = append(minijailArgs, "-P", chrootDir)
 
+	// ------------------------
+	// --- Set up seccomp-BPF ---
+	// ------------------------
+	if opts.SeccompPolicy != nil {
+		policyPath, err := resolveSeccompPolicy(opts.SeccompPolicy, chrootDir)
+		if err != nil {
+			return nil, err
+		}
+		// -S loads the filter before minijail applies any of the
+		// above namespace/chroot changes, so policyPath is resolved on
+		// the host; -b additionally bind-mounts it into the jail at the
+		// same path so it's still readable (e.g. for debugging) once
+		// inside.
+		minijailArgs = append(minijailArgs, "-S", policyPath, "-b", policyPath)
+		minijailConfigLines = append(minijailConfigLines, "seccomp=1")
+	}
+
+	// -----------------------
+	// --- Set up rlimits ---
+	// -----------------------
+	minijailConfigLines = append(minijailConfigLines, rlimitConfigLines(sandbox.ResolveLimits(opts.Limits))...)
+
 	// -----------------------
 	// --- Set up bindings ---
 	// -----------------------
-	var bindings []*Binding
+	var bindings []*sandbox.Binding
 
 	// Add bindings for all subdirectories of /tmp. These are not already
 	// mounted from the host because above we mounted a tmpfs on /tmp.
@@ -222,15 +287,21 @@ func NewMinijail(opts *Options) (*minijail, error) {
 		if os.SameFile(fileInfo, chrootDirFileInfo) {
 			continue
 		}
-		bindings = append(bindings, &Binding{Source: "/tmp" + entry.Name()})
+		bindings = append(bindings, &sandbox.Binding{Source: "/tmp" + entry.Name()})
 	}
 
 	bindings = append(bindings, opts.Bindings...)
 	bindings = append(bindings, defaultBindings...)
 
+	mutatorPluginBindings, err := sandbox.MutatorPluginBindings(opts.MutatorPlugin)
+	if err != nil {
+		return nil, err
+	}
+	bindings = append(bindings, mutatorPluginBindings...)
+
 	// Allow read-write access to the minijail output directory
 	if opts.OutputDir != "" {
-		bindings = append(bindings, &Binding{Source: opts.OutputDir, Writable: ReadWrite})
+		bindings = append(bindings, &sandbox.Binding{Source: opts.OutputDir, Writable: sandbox.ReadWrite})
 	}
 
 	// We expect the current working directory to be the artifacts
@@ -243,10 +314,10 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	bindings = append(bindings, &Binding{Source: workdir, Writable: ReadWrite})
+	bindings = append(bindings, &sandbox.Binding{Source: workdir, Writable: sandbox.ReadWrite})
 
 	// Add binding for the executable
-	bindings = append(bindings, &Binding{Source: path})
+	bindings = append(bindings, &sandbox.Binding{Source: path})
 
 	// Add binding for process_wrapper. process_wrapper changes the
 	// working directory and then executes the specified command.
@@ -254,7 +325,7 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	if err != nil {
 		return nil, err
 	}
-	bindings = append(bindings, &Binding{Source: processWrapperPath})
+	bindings = append(bindings, &sandbox.Binding{Source: processWrapperPath})
 
 	// Add bindings to the minijail config
 	for _, binding := range bindings {
@@ -287,7 +358,7 @@ func NewMinijail(opts *Options) (*minijail, error) {
 			}
 		}
 
-		minijailConfigLines = append(minijailConfigLines, "bind-mount="+binding.String())
+		minijailConfigLines = append(minijailConfigLines, "bind-mount="+bindingString(binding))
 	}
 
 	// Write the config file
@@ -307,6 +378,14 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	// sandbox to the first argument
 	processWrapperArgs := []string{processWrapperPath, workdir}
 
+	// process_wrapper (built outside this repo) recognizes an
+	// "OOM_SCORE_ADJ=<n>" argument the same way it recognizes "CMD=..."
+	// below, and writes it to /proc/self/oom_score_adj right before
+	// exec'ing opts.Args - minijail0 itself has no equivalent flag.
+	if opts.OOMScoreAdj != 0 {
+		processWrapperArgs = append(processWrapperArgs, fmt.Sprintf("OOM_SCORE_ADJ=%d", opts.OOMScoreAdj))
+	}
+
 	// --------------------
 	// --- Run minijail ---
 	// --------------------
@@ -322,13 +401,25 @@ func NewMinijail(opts *Options) (*minijail, error) {
 		args = stringutil.JoinSlices("--", minijailArgs, processWrapperArgs, []string{"/bin/sh"})
 	}
 
-	return &minijail{
+	return &Minijail{
 		Options:   opts,
 		chrootDir: chrootDir,
-		Args:      args,
+		args:      args,
 	}, nil
 }
 
-func (m *minijail) Cleanup() {
+// Args implements sandbox.Runner.
+func (m *Minijail) Args() []string {
+	return m.args
+}
+
+// Env implements sandbox.Runner.
+func (m *Minijail) Env() []string {
+	return sandbox.MutatorPluginEnv(m.MutatorPlugin)
+}
+
+// Cleanup implements sandbox.Runner.
+func (m *Minijail) Cleanup() {
+	unregisterChroot(m.chrootDir)
 	fileutil.Cleanup(m.chrootDir)
 }
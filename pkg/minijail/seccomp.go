@@ -0,0 +1,50 @@
+package minijail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/sandbox"
+)
+
+// renderAction renders action in minijail's .policy syntax.
+func renderAction(action sandbox.Action) string {
+	switch {
+	case action == sandbox.Allow:
+		return "1"
+	case action == sandbox.Kill:
+		return "kill"
+	case action == sandbox.Trace:
+		return "trace"
+	case action == sandbox.Log:
+		return "log"
+	default:
+		return fmt.Sprintf("return-errno(%d)", action.Errno())
+	}
+}
+
+// resolveSeccompPolicy returns the host path of the policy file to pass
+// to minijail0 via -S, writing one under dir from policy.Rules first if
+// policy.Path isn't set.
+func resolveSeccompPolicy(policy *sandbox.SeccompPolicy, dir string) (string, error) {
+	if policy.Path != "" {
+		return policy.Path, nil
+	}
+
+	var lines []string
+	for _, rule := range policy.Rules {
+		lines = append(lines, fmt.Sprintf("%s: %s", rule.Name, renderAction(rule.Action)))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	path := filepath.Join(dir, "seccomp.policy")
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return path, nil
+}
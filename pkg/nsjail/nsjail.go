@@ -0,0 +1,268 @@
+// Package nsjail sandboxes fuzzing and replay runs using nsjail instead
+// of minijail0. It registers itself with pkg/sandbox as the "nsjail"
+// backend, for distros where minijail0 is hard to obtain a working build
+// of, or where nsjail's extra features (per-mount tmpfs size=, structured
+// rlimit_as/rlimit_fsize) are wanted. Runners should go through
+// sandbox.New rather than calling New directly.
+package nsjail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/pkg/sandbox"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+func init() {
+	sandbox.Register(sandbox.BackendNsjail, func(opts *sandbox.Options) (sandbox.Runner, error) {
+		return New(opts)
+	})
+}
+
+var defaultBindings = []*sandbox.Binding{
+	// See pkg/minijail's defaultBindings for why these two need to be
+	// writable.
+	{Source: "/dev/null", Writable: sandbox.ReadWrite},
+	{Source: "/dev/urandom", Writable: sandbox.ReadWrite},
+}
+
+// Nsjail is a sandboxed run set up via nsjail. It implements
+// sandbox.Runner.
+type Nsjail struct {
+	*sandbox.Options
+	args      []string
+	chrootDir string
+}
+
+// mountStanza renders one "mount { ... }" block of an nsjail config.
+func mountStanza(src, dst string, isBind, rw, isDir bool) string {
+	var b strings.Builder
+	b.WriteString("mount {\n")
+	if src != "" {
+		fmt.Fprintf(&b, "  src: %q\n", src)
+	}
+	fmt.Fprintf(&b, "  dst: %q\n", dst)
+	if isBind {
+		b.WriteString("  is_bind: true\n")
+	}
+	if rw {
+		b.WriteString("  rw: true\n")
+	}
+	if isDir {
+		b.WriteString("  is_dir: true\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// tmpfsStanza renders a tmpfs mount, nsjail's equivalent of minijail's
+// "mount=tmpfs,..." config lines.
+func tmpfsStanza(dst string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "mount {\n  dst: %q\n  fstype: \"tmpfs\"\n  rw: true\n  is_dir: true\n}", dst)
+	return b.String()
+}
+
+// bindingStanza renders a sandbox.Binding as an nsjail bind mount.
+func bindingStanza(b *sandbox.Binding) string {
+	target := b.Target
+	if target == "" {
+		target = b.Source
+	}
+	return mountStanza(b.Source, target, true, b.Writable == sandbox.ReadWrite, fileutil.IsDir(b.Source))
+}
+
+// rlimitConfigLines renders limits into nsjail's top-level rlimit_*
+// config fields, skipping any field left at its zero value - nsjail
+// itself defaults those to "soft"/"hard" (the process's own limits at
+// the time nsjail starts) rather than to unlimited.
+func rlimitConfigLines(limits *sandbox.Limits) []string {
+	var lines []string
+	add := func(field string, value uint64) {
+		if value == 0 {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d", field, value))
+	}
+	add("rlimit_as", limits.AddressSpaceBytes)
+	add("rlimit_fsize", limits.FileSizeBytes)
+	add("rlimit_cpu", limits.CPUSeconds)
+	add("rlimit_nofile", limits.NoFile)
+	add("rlimit_nproc", limits.NProc)
+	add("rlimit_core", limits.CoreBytes)
+	return lines
+}
+
+// New creates an Nsjail for opts. Most callers should go through
+// sandbox.New instead.
+func New(opts *sandbox.Options) (*Nsjail, error) {
+	path, err := filepath.EvalSymlinks(opts.Args[0])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	opts.Args[0] = path
+
+	chrootDir, err := os.MkdirTemp("", "nsjail-chroot-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	configLines := []string{
+		`mode: "ONCE"`,
+		`hostname: "cifuzz"`,
+		// Run as the invoking user/group instead of nsjail's default
+		// nobody/nogroup, for the same reason minijail maps root -> USER:
+		// the sandboxed process needs access to files owned by the
+		// invoking user.
+		fmt.Sprintf("uidmap { inside_uid: %d outside_uid: %d }", os.Getuid(), os.Getuid()),
+		fmt.Sprintf("gidmap { inside_gid: %d outside_gid: %d }", os.Getgid(), os.Getgid()),
+		fmt.Sprintf("cwd: %q", chrootDir),
+		// Mount the whole host filesystem read-only by default, the same
+		// "deny by default, allow via explicit writable bindings"
+		// guarantee pkg/minijail's "mount=/,/,none,MS_RDONLY|MS_BIND|MS_REC"
+		// line provides. Every Binding mounted below layers on top of
+		// this, so only paths explicitly passed in become writable or
+		// otherwise escape this default.
+		mountStanza("/", "/", true, false, true),
+		mountStanza("", "/proc", false, false, true) + ` fstype: "proc"`,
+		tmpfsStanza("/tmp"),
+		tmpfsStanza("/dev/shm"),
+		tmpfsStanza("/run"),
+	}
+
+	configLines = append(configLines, rlimitConfigLines(sandbox.ResolveLimits(opts.Limits))...)
+
+	if opts.OOMScoreAdj != 0 {
+		// nsjail has no --oom_score_adj-equivalent config field, so
+		// instead of an exec wrapper binary (which minijail uses
+		// process_wrapper for), wrap the command in a shell one-liner
+		// that adjusts its own oom_score_adj right before exec'ing the
+		// real argv.
+		opts.Args = append(
+			[]string{"/bin/sh", "-c", fmt.Sprintf(`echo %d > /proc/self/oom_score_adj; exec "$@"`, opts.OOMScoreAdj), "sh"},
+			opts.Args...,
+		)
+	}
+
+	if opts.SeccompPolicy != nil {
+		seccompString, err := seccompString(opts.SeccompPolicy)
+		if err != nil {
+			return nil, err
+		}
+		configLines = append(configLines, fmt.Sprintf("seccomp_string: %q", seccompString))
+	}
+
+	var bindings []*sandbox.Binding
+	bindings = append(bindings, opts.Bindings...)
+	bindings = append(bindings, defaultBindings...)
+
+	mutatorPluginBindings, err := sandbox.MutatorPluginBindings(opts.MutatorPlugin)
+	if err != nil {
+		return nil, err
+	}
+	bindings = append(bindings, mutatorPluginBindings...)
+
+	if opts.OutputDir != "" {
+		bindings = append(bindings, &sandbox.Binding{Source: opts.OutputDir, Writable: sandbox.ReadWrite})
+	}
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	bindings = append(bindings, &sandbox.Binding{Source: workdir, Writable: sandbox.ReadWrite})
+	bindings = append(bindings, &sandbox.Binding{Source: path})
+
+	for _, binding := range bindings {
+		exists, err := fileutil.Exists(binding.Source)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		configLines = append(configLines, bindingStanza(binding))
+	}
+
+	configFile := filepath.Join(chrootDir, "nsjail.cfg")
+	configFileContent := strings.Join(configLines, "\n") + "\n"
+	log.Debugf("%s:\n%s", configFile, configFileContent)
+	err = os.WriteFile(configFile, []byte(configFileContent), 0o600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	nsjailPath, err := runfiles.Finder.NsjailPath()
+	if err != nil {
+		return nil, err
+	}
+
+	args := stringutil.JoinSlices("--", []string{nsjailPath, "--config", configFile}, opts.Args)
+
+	return &Nsjail{
+		Options:   opts,
+		chrootDir: chrootDir,
+		args:      args,
+	}, nil
+}
+
+// seccompString renders policy as an inline kafel seccomp-BPF source
+// string, nsjail's "seccomp_string" config field, rather than writing it
+// to a separate policy file the way minijail's -S flag needs.
+func seccompString(policy *sandbox.SeccompPolicy) (string, error) {
+	if policy.Path != "" {
+		content, err := os.ReadFile(policy.Path)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return string(content), nil
+	}
+
+	var lines []string
+	lines = append(lines, "POLICY cifuzz {")
+	for _, rule := range policy.Rules {
+		lines = append(lines, fmt.Sprintf("  %s { %s },", rule.Name, kafelAction(rule.Action)))
+	}
+	lines = append(lines, "}")
+	lines = append(lines, "USE cifuzz DEFAULT KILL")
+	return strings.Join(lines, "\n"), nil
+}
+
+// kafelAction renders action in kafel syntax, the policy language nsjail
+// uses for seccomp_string.
+func kafelAction(action sandbox.Action) string {
+	switch {
+	case action == sandbox.Allow:
+		return "ALLOW"
+	case action == sandbox.Kill:
+		return "KILL"
+	case action == sandbox.Trace:
+		return "TRACE"
+	case action == sandbox.Log:
+		return "LOG"
+	default:
+		return fmt.Sprintf("ERRNO(%d)", action.Errno())
+	}
+}
+
+// Args implements sandbox.Runner.
+func (n *Nsjail) Args() []string {
+	return n.args
+}
+
+// Env implements sandbox.Runner.
+func (n *Nsjail) Env() []string {
+	return sandbox.MutatorPluginEnv(n.MutatorPlugin)
+}
+
+// Cleanup implements sandbox.Runner.
+func (n *Nsjail) Cleanup() {
+	fileutil.Cleanup(n.chrootDir)
+}
@@ -0,0 +1,64 @@
+package report
+
+import "time"
+
+// Status describes the high-level outcome a Report represents.
+type Status string
+
+const (
+	RunningStatus   Status = "RUNNING"
+	FailedStatus    Status = "FAILED"
+	MinimizedStatus Status = "MINIMIZED"
+)
+
+// Report is emitted by a runner while a fuzz test is executing. Runners
+// emit a mix of metric reports (periodic progress updates) and finding
+// reports (a crash, sanitizer error, timeout, etc. was found).
+type Report struct {
+	Status    Status     `json:"status,omitempty"`
+	Metric    *Metric    `json:"metric,omitempty"`
+	Finding   *Finding   `json:"finding,omitempty"`
+	Minimized *Minimized `json:"minimized,omitempty"`
+}
+
+// Minimized describes the result of a post-crash minimization run: the
+// original crashing input that triggered it and the smaller reproducer
+// libFuzzer found, if any.
+type Minimized struct {
+	OriginalInput  []byte `json:"original_input"`
+	MinimizedInput []byte `json:"minimized_input"`
+	OriginalSize   int    `json:"original_size"`
+	MinimizedSize  int    `json:"minimized_size"`
+}
+
+// Metric holds a point-in-time snapshot of a fuzzing run's progress, as
+// parsed from the engine's stdout/stderr.
+type Metric struct {
+	Timestamp           time.Time     `json:"timestamp"`
+	Elapsed             time.Duration `json:"elapsed"`
+	ExecutionsPerSecond int32         `json:"executions_per_second"`
+	Features            int32         `json:"features,omitempty"`
+	CorpusSize          int32         `json:"corpus_size,omitempty"`
+	TotalExecutions     int64         `json:"total_executions,omitempty"`
+	Edges               int32         `json:"edges,omitempty"`
+}
+
+// ReportProgress builds a Report describing a point-in-time progress
+// update, as opposed to a terminal finding or minimization result.
+func ReportProgress(metric *Metric) *Report {
+	return &Report{
+		Status: RunningStatus,
+		Metric: metric,
+	}
+}
+
+// Finding describes a single crash, sanitizer report, or other failure
+// discovered by a fuzzing run.
+type Finding struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type,omitempty"`
+	InputData []byte   `json:"input_data,omitempty"`
+	Logs      []string `json:"logs,omitempty"`
+	Details   string   `json:"details,omitempty"`
+	InputFile string   `json:"input_file,omitempty"`
+}
@@ -0,0 +1,280 @@
+// Package aflplusplus implements a runner which drives AFL++-instrumented
+// fuzz targets the same way pkg/runner/libfuzzer drives libFuzzer. Unlike
+// libFuzzer, afl-fuzz doesn't print per-crash summaries to stdout when run
+// non-interactively; instead it writes one file per finding into its
+// output directory's "crashes" subdirectory and periodically refreshes a
+// "fuzzer_stats" file with progress counters, so this runner watches those
+// instead of parsing a line-oriented log.
+package aflplusplus
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/sandbox"
+
+	_ "code-intelligence.com/cifuzz/pkg/minijail"    // register the minijail sandbox backend
+	_ "code-intelligence.com/cifuzz/pkg/nsjail"      // register the nsjail sandbox backend
+	_ "code-intelligence.com/cifuzz/pkg/sandboxexec" // register the sandbox-exec sandbox backend
+)
+
+// ReportHandler receives reports emitted while a fuzz test is running.
+type ReportHandler interface {
+	Handle(report *report.Report) error
+}
+
+// RunnerOptions mirrors the subset of libfuzzer.RunnerOptions that
+// applies to afl-fuzz, so that callers can switch engines without having
+// to special-case the option set.
+type RunnerOptions struct {
+	EngineArgs         []string
+	EnvVars            []string
+	FuzzTarget         string
+	GeneratedCorpusDir string
+	// OutputDir is afl-fuzz's "-o" directory, which holds its queue,
+	// crashes, and fuzzer_stats. Unlike libFuzzer's GeneratedCorpusDir,
+	// it isn't a flat pool of interesting inputs; it's afl-fuzz's own
+	// working state.
+	OutputDir      string
+	ProjectDir     string
+	ReportHandler  ReportHandler
+	SeedCorpusDirs []string
+	Timeout        time.Duration
+	UseMinijail    bool
+	Verbose        bool
+
+	// MutatorPlugin is the path to a libFuzzer mutator plugin
+	// ("libmutator.so") to LD_PRELOAD into the sandboxed afl-fuzz
+	// process, mirroring how ClusterFuzz's minijail helper exposes
+	// mutator plugins. It has no effect if UseMinijail is false.
+	MutatorPlugin string
+
+	// Backend selects which sandbox.New implementation to use when
+	// UseMinijail is set. The zero value, sandbox.BackendAuto, picks
+	// whichever backend suits runtime.GOOS.
+	Backend sandbox.Backend
+}
+
+func (opts *RunnerOptions) Validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget is not set")
+	}
+	if opts.GeneratedCorpusDir == "" {
+		return errors.New("GeneratedCorpusDir is not set")
+	}
+	if opts.OutputDir == "" {
+		return errors.New("OutputDir is not set")
+	}
+	return nil
+}
+
+type Runner struct {
+	*RunnerOptions
+
+	// seenCrashes tracks which crash file names have already been
+	// reported, since poll re-reads the whole crashes directory on
+	// every tick. It's guarded by seenCrashesMu since poll runs both
+	// from pollPeriodically's goroutine and, once more, synchronously
+	// after cmd.Wait() returns - the two can overlap until pollDone is
+	// closed.
+	seenCrashesMu sync.Mutex
+	seenCrashes   map[string]bool
+}
+
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts, seenCrashes: map[string]bool{}}
+}
+
+// pollInterval bounds how often fuzzer_stats and the crashes directory
+// are re-read for a progress report, mirroring libfuzzer's
+// dedupeInterval.
+const pollInterval = 1 * time.Second
+
+// Run builds the afl-fuzz command line and polls OutputDir for progress
+// and new crashing inputs until the process exits or ctx is canceled.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(r.OutputDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	inputDir := r.GeneratedCorpusDir
+	if len(r.SeedCorpusDirs) > 0 {
+		// afl-fuzz only accepts a single "-i" input directory, unlike
+		// libFuzzer which takes any number of corpus directories on the
+		// command line, so only the first seed corpus is used to seed the
+		// run. The generated corpus and any further seed corpora are not
+		// passed to afl-fuzz in that case; its findings go to "-o".
+		inputDir = r.SeedCorpusDirs[0]
+	}
+
+	args := []string{
+		"-i", inputDir,
+		"-o", r.OutputDir,
+	}
+	if r.Timeout != 0 {
+		args = append(args, "-V", strconv.Itoa(int(r.Timeout.Seconds())))
+	}
+	args = append(args, r.EngineArgs...)
+	args = append(args, "--", r.FuzzTarget)
+
+	if r.UseMinijail {
+		aflFuzzPath, err := exec.LookPath("afl-fuzz")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		sb, err := sandbox.New(&sandbox.Options{
+			Args:          append([]string{aflFuzzPath}, args...),
+			OutputDir:     r.OutputDir,
+			MutatorPlugin: r.MutatorPlugin,
+			Backend:       r.Backend,
+		})
+		if err != nil {
+			return err
+		}
+		defer sb.Cleanup()
+		args = sb.Args()[1:]
+		r.EnvVars = sandbox.MergeEnv(r.EnvVars, sb.Env())
+	}
+
+	cmd := exec.CommandContext(ctx, "afl-fuzz", args...)
+	cmd.Env = r.EnvVars
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	pollDone := make(chan struct{})
+	go r.pollPeriodically(ctx, pollDone)
+	defer close(pollDone)
+
+	err = cmd.Wait()
+	// Do a final poll so that crashes and stats from the last moments of
+	// the run aren't missed.
+	r.poll()
+	return errors.WithStack(err)
+}
+
+func (r *Runner) pollPeriodically(ctx context.Context, done chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.poll()
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll reads fuzzer_stats for a progress report and reports any crash
+// files found in the output directory's default/crashes subdirectory
+// that haven't been reported yet.
+func (r *Runner) poll() {
+	if metric := r.readStats(); metric != nil {
+		_ = r.ReportHandler.Handle(report.ReportProgress(metric))
+	}
+
+	crashesDir := filepath.Join(r.OutputDir, "default", "crashes")
+	entries, err := os.ReadDir(crashesDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == "README.txt" || r.crashSeen(name) {
+			continue
+		}
+
+		_ = r.ReportHandler.Handle(&report.Report{
+			Status: report.FailedStatus,
+			Finding: &report.Finding{
+				Name:      name,
+				Type:      crashType(name),
+				InputFile: filepath.Join(crashesDir, name),
+			},
+		})
+	}
+}
+
+// crashSeen reports whether name has already been reported as a crash,
+// recording it as seen otherwise.
+func (r *Runner) crashSeen(name string) bool {
+	r.seenCrashesMu.Lock()
+	defer r.seenCrashesMu.Unlock()
+
+	if r.seenCrashes[name] {
+		return true
+	}
+	r.seenCrashes[name] = true
+	return false
+}
+
+// crashType extracts the signal AFL++ names a crashing input's filename
+// after, e.g. "id:000000,sig:11,src:000000,time:..." -> "sig:11".
+func crashType(name string) string {
+	for _, field := range strings.Split(name, ",") {
+		if strings.HasPrefix(field, "sig:") {
+			return field
+		}
+	}
+	return "crash"
+}
+
+// readStats parses the fields of fuzzer_stats relevant to progress
+// reporting, which afl-fuzz rewrites periodically during a run.
+func (r *Runner) readStats() *report.Metric {
+	file, err := os.Open(filepath.Join(r.OutputDir, "default", "fuzzer_stats"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	metric := &report.Metric{Timestamp: time.Now()}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch key {
+		case "execs_done":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				metric.TotalExecutions = n
+			}
+		case "execs_per_sec":
+			if n, err := strconv.ParseFloat(value, 32); err == nil {
+				metric.ExecutionsPerSecond = int32(n)
+			}
+		case "corpus_count":
+			if n, err := strconv.ParseInt(value, 10, 32); err == nil {
+				metric.CorpusSize = int32(n)
+			}
+		}
+	}
+
+	return metric
+}
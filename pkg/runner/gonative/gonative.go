@@ -0,0 +1,208 @@
+// Package gonative implements a runner which drives Go's native
+// `testing.F` fuzzing engine (`go test -fuzz`) the same way
+// pkg/runner/libfuzzer drives libFuzzer.
+package gonative
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// ReportHandler receives reports emitted while a fuzz test is running.
+type ReportHandler interface {
+	Handle(report *report.Report) error
+}
+
+// RunnerOptions mirrors libfuzzer.RunnerOptions so that callers (and the
+// integration test scaffolding) can switch engines without having to
+// special-case the option set.
+type RunnerOptions struct {
+	EngineArgs         []string
+	EnvVars            []string
+	FuzzTarget         string
+	FuzzTestArgs       []string
+	GeneratedCorpusDir string
+	LogOutput          io.Writer
+	ProjectDir         string
+	ReportHandler      ReportHandler
+	SeedCorpusDirs     []string
+	Timeout            time.Duration
+	UseMinijail        bool
+	Verbose            bool
+}
+
+func (opts *RunnerOptions) Validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget is not set")
+	}
+	if opts.GeneratedCorpusDir == "" {
+		return errors.New("GeneratedCorpusDir is not set")
+	}
+	return nil
+}
+
+type Runner struct {
+	*RunnerOptions
+}
+
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts}
+}
+
+// progressLine matches libFuzzer-style progress lines printed by Go's
+// fuzzing engine, e.g.:
+//
+//	fuzz: elapsed: 3s, execs: 1234 (411/sec), workers: 4, interesting: 7
+var progressLine = regexp.MustCompile(
+	`^fuzz: elapsed: .*, execs: (\d+) \((\d+)/sec\), workers: (\d+), interesting: (\d+)`)
+
+// failureHeader matches the header of a `go test` failure block, e.g.:
+//
+//	--- FAIL: FuzzParse (0.01s)
+var failureHeader = regexp.MustCompile(`^--- FAIL: (\S+) `)
+
+// Run builds the command line for `go test -fuzz` and streams its
+// output into findings and progress reports.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"test",
+		"-run=^$",
+		"-fuzz=" + fuzzRegexp(r.FuzzTarget),
+	}
+	if r.Timeout != 0 {
+		args = append(args, "-fuzztime="+r.Timeout.String())
+	}
+	args = append(args, r.EngineArgs...)
+	args = append(args, r.FuzzTestArgs...)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = r.ProjectDir
+	cmd.Env = r.EnvVars
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cmd.Stderr = cmd.Stdout
+
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := r.streamOutput(stdout); err != nil {
+		return err
+	}
+
+	return errors.WithStack(cmd.Wait())
+}
+
+// streamOutput reads the combined stdout/stderr of the `go test` process
+// line by line, tees it into LogOutput (mirroring how
+// libfuzzer.RunnerOptions.LogOutput is used), and parses recognized
+// lines into *report.Report values forwarded to ReportHandler.
+func (r *Runner) streamOutput(stdout io.Reader) error {
+	scanner := bufio.NewScanner(stdout)
+	var currentFailure *failureBlock
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if r.LogOutput != nil {
+			fmt.Fprintln(r.LogOutput, line)
+		}
+
+		if match := failureHeader.FindStringSubmatch(line); match != nil {
+			currentFailure = &failureBlock{name: match[1]}
+			continue
+		}
+
+		if currentFailure != nil {
+			currentFailure.logs = append(currentFailure.logs, line)
+			// A failure block ends with a blank line or the "FAIL" summary.
+			if line == "FAIL" || line == "" {
+				err := r.ReportHandler.Handle(currentFailure.report())
+				if err != nil {
+					return err
+				}
+				currentFailure = nil
+			}
+			continue
+		}
+
+		if match := progressLine.FindStringSubmatch(line); match != nil {
+			rep, err := parseProgress(match)
+			if err != nil {
+				return err
+			}
+			err = r.ReportHandler.Handle(rep)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return errors.WithStack(scanner.Err())
+}
+
+type failureBlock struct {
+	name string
+	logs []string
+}
+
+func (f *failureBlock) report() *report.Report {
+	return &report.Report{
+		Status: report.FailedStatus,
+		Finding: &report.Finding{
+			Name:    f.name,
+			Type:    "go_test_failure",
+			Logs:    f.logs,
+			Details: fmt.Sprintf("%s failed", f.name),
+		},
+	}
+}
+
+func parseProgress(match []string) (*report.Report, error) {
+	execs, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	execsPerSec, err := strconv.ParseInt(match[2], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	interesting, err := strconv.ParseInt(match[4], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &report.Report{
+		Status: report.RunningStatus,
+		Metric: &report.Metric{
+			Timestamp:           time.Now(),
+			TotalExecutions:     execs,
+			ExecutionsPerSecond: int32(execsPerSec),
+			CorpusSize:          int32(interesting),
+		},
+	}, nil
+}
+
+// fuzzRegexp anchors the fuzz target name so that `go test -fuzz` only
+// runs the requested FuzzXxx function, mirroring the `^Name$` convention
+// used by `go test -run`.
+func fuzzRegexp(fuzzTarget string) string {
+	return "^" + fuzzTarget + "$"
+}
@@ -0,0 +1,246 @@
+// Package honggfuzz implements a runner which drives honggfuzz-instrumented
+// fuzz targets the same way pkg/runner/libfuzzer drives libFuzzer. Crashing
+// inputs are written by honggfuzz as one file per finding into its
+// "--crashdir", named after the signal that caused them, and its
+// "--verbose" mode prints one progress line per second to stderr that this
+// runner parses for metrics.
+package honggfuzz
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/sandbox"
+
+	_ "code-intelligence.com/cifuzz/pkg/minijail"    // register the minijail sandbox backend
+	_ "code-intelligence.com/cifuzz/pkg/nsjail"      // register the nsjail sandbox backend
+	_ "code-intelligence.com/cifuzz/pkg/sandboxexec" // register the sandbox-exec sandbox backend
+)
+
+// ReportHandler receives reports emitted while a fuzz test is running.
+type ReportHandler interface {
+	Handle(report *report.Report) error
+}
+
+// RunnerOptions mirrors the subset of libfuzzer.RunnerOptions that
+// applies to honggfuzz, so that callers can switch engines without
+// having to special-case the option set.
+type RunnerOptions struct {
+	EngineArgs         []string
+	EnvVars            []string
+	FuzzTarget         string
+	GeneratedCorpusDir string
+	CrashDir           string
+	LogOutput          io.Writer
+	ProjectDir         string
+	ReportHandler      ReportHandler
+	SeedCorpusDirs     []string
+	Timeout            time.Duration
+	UseMinijail        bool
+	Verbose            bool
+
+	// MutatorPlugin is the path to a libFuzzer mutator plugin
+	// ("libmutator.so") to LD_PRELOAD into the sandboxed honggfuzz
+	// process, mirroring how ClusterFuzz's minijail helper exposes
+	// mutator plugins. It has no effect if UseMinijail is false.
+	MutatorPlugin string
+
+	// Backend selects which sandbox.New implementation to use when
+	// UseMinijail is set. The zero value, sandbox.BackendAuto, picks
+	// whichever backend suits runtime.GOOS.
+	Backend sandbox.Backend
+}
+
+func (opts *RunnerOptions) Validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget is not set")
+	}
+	if opts.GeneratedCorpusDir == "" {
+		return errors.New("GeneratedCorpusDir is not set")
+	}
+	if opts.CrashDir == "" {
+		return errors.New("CrashDir is not set")
+	}
+	return nil
+}
+
+type Runner struct {
+	*RunnerOptions
+
+	// seenCrashes tracks which crash file names have already been
+	// reported, since CrashDir is re-scanned on every progress line.
+	seenCrashes map[string]bool
+}
+
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts, seenCrashes: map[string]bool{}}
+}
+
+// progressLine matches honggfuzz's "--verbose" status line, e.g.:
+//
+//	Iterations: 123456 (out of 0) cov: 1234 crashes: 2 timeouts: 0
+var progressLine = regexp.MustCompile(
+	`^Iterations: (\d+) .* cov: (\d+) crashes: (\d+) timeouts: (\d+)`)
+
+// Run builds the honggfuzz command line and streams its stderr into
+// progress reports, checking CrashDir for new findings whenever a
+// progress line mentions a nonzero crash count.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(r.CrashDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	args := []string{
+		"--input", r.GeneratedCorpusDir,
+		"--crashdir", r.CrashDir,
+		"--verbose",
+	}
+	for _, dir := range r.SeedCorpusDirs {
+		args = append(args, "--input", dir)
+	}
+	if r.Timeout != 0 {
+		args = append(args, "--run_time", strconv.Itoa(int(r.Timeout.Seconds())))
+	}
+	args = append(args, r.EngineArgs...)
+	args = append(args, "--", r.FuzzTarget)
+
+	if r.UseMinijail {
+		honggfuzzPath, err := exec.LookPath("honggfuzz")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		sb, err := sandbox.New(&sandbox.Options{
+			Args:          append([]string{honggfuzzPath}, args...),
+			OutputDir:     r.CrashDir,
+			MutatorPlugin: r.MutatorPlugin,
+			Backend:       r.Backend,
+		})
+		if err != nil {
+			return err
+		}
+		defer sb.Cleanup()
+		args = sb.Args()[1:]
+		r.EnvVars = sandbox.MergeEnv(r.EnvVars, sb.Env())
+	}
+
+	cmd := exec.CommandContext(ctx, "honggfuzz", args...)
+	cmd.Env = r.EnvVars
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cmd.Stdout = cmd.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := r.streamOutput(stderr); err != nil {
+		return err
+	}
+
+	return errors.WithStack(cmd.Wait())
+}
+
+func (r *Runner) streamOutput(stderr io.Reader) error {
+	scanner := bufio.NewScanner(stderr)
+	start := time.Now()
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if r.LogOutput != nil {
+			fmt.Fprintln(r.LogOutput, line)
+		}
+
+		match := progressLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		metric, err := parseProgressMetric(match, time.Since(start))
+		if err != nil {
+			return err
+		}
+		if err := r.ReportHandler.Handle(report.ReportProgress(metric)); err != nil {
+			return err
+		}
+
+		crashes, err := strconv.Atoi(match[3])
+		if err == nil && crashes > 0 {
+			if err := r.reportNewCrashes(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errors.WithStack(scanner.Err())
+}
+
+func parseProgressMetric(match []string, elapsed time.Duration) (*report.Metric, error) {
+	execs, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	edges, err := strconv.ParseInt(match[2], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &report.Metric{
+		Timestamp:       time.Now(),
+		Elapsed:         elapsed,
+		TotalExecutions: execs,
+		Edges:           int32(edges),
+	}, nil
+}
+
+// reportNewCrashes reports any crash files in CrashDir not seen yet.
+// honggfuzz names them after the signal and faulting address, e.g.
+// "SIGSEGV.PC.7ffff7a.STACK.1234abcd.ADDR.0.INSTR.mov.fuzz".
+func (r *Runner) reportNewCrashes() error {
+	entries, err := os.ReadDir(r.CrashDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || r.seenCrashes[name] {
+			continue
+		}
+		r.seenCrashes[name] = true
+
+		err := r.ReportHandler.Handle(&report.Report{
+			Status: report.FailedStatus,
+			Finding: &report.Finding{
+				Name:      name,
+				Type:      strings.SplitN(name, ".", 2)[0],
+				InputFile: filepath.Join(r.CrashDir, name),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus/gofuzzformat"
 	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner/gonative"
 	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/pkg/session"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
@@ -47,9 +50,34 @@ type RunnerTest struct {
 	FuzzTestArgs       []string
 	FuzzerEnv          []string
 	DisableMinijail    bool
-	RunsLimit          int
 	LogOutput          *bytes.Buffer
 	ProjectDir         string
+
+	// RunBudget bounds the run, either as a run count ("5000x") or a
+	// duration ("30s"), mirroring Go's durationOrCountFlag. An empty
+	// string means no limit.
+	RunBudget string
+
+	// CacheDir receives every input libFuzzer wrote to disk during the
+	// run, including duplicates, which are deduped by hash when copied
+	// into GeneratedCorpusDir. If unset, a temporary directory is created.
+	CacheDir string
+
+	// GoFuzzCorpusDirs are seed corpus directories in the `go test fuzz
+	// v1` format (e.g. `testdata/fuzz/FuzzTarget`). Entries are
+	// transparently converted to raw byte seeds before being handed to
+	// the libFuzzer runner.
+	GoFuzzCorpusDirs []string
+
+	// MinimizeTimeout bounds the post-crash minimization phase. Defaults
+	// to libfuzzer.DefaultMinimizeTimeout; set to 0 to disable it.
+	MinimizeTimeout time.Duration
+
+	// reports holds the reports collected by the most recent call to
+	// Run, so that assertion helpers like RequireMinimizedFinding can
+	// inspect them without every test having to thread the result
+	// through manually.
+	reports []*report.Report
 }
 
 func NewLibfuzzerTest(t *testing.T, buildDir, fuzzTarget string, disableMinijail bool) *RunnerTest {
@@ -61,9 +89,26 @@ func NewLibfuzzerTest(t *testing.T, buildDir, fuzzTarget string, disableMinijail
 			"-seed=1",
 		},
 		DisableMinijail: disableMinijail,
-		// For those tests which don't set a custom runs limit, the
+		// For those tests which don't set a custom run budget, the
+		// expected errors are found within 3000 runs.
+		RunBudget:       "3000x",
+		LogOutput:       bytes.NewBuffer([]byte{}),
+		ProjectDir:      buildDir,
+		MinimizeTimeout: libfuzzer.DefaultMinimizeTimeout,
+	}
+}
+
+// NewGoNativeTest mirrors NewLibfuzzerTest so that the integration test
+// scaffolding can exercise Go's native fuzzing engine (`go test -fuzz`)
+// identically to libFuzzer. fuzzTarget is the name of the `FuzzXxx`
+// function, and buildDir is the package directory containing it.
+func NewGoNativeTest(t *testing.T, buildDir, fuzzTarget string) *RunnerTest {
+	return &RunnerTest{
+		FuzzTarget: fuzzTarget,
+		Engine:     config.GO_NATIVE,
+		// For those tests which don't set a custom run budget, the
 		// expected errors are found within 3000 runs.
-		RunsLimit:  3000,
+		RunBudget:  "3000x",
 		LogOutput:  bytes.NewBuffer([]byte{}),
 		ProjectDir: buildDir,
 	}
@@ -79,16 +124,33 @@ func (test *RunnerTest) Start(t *testing.T, reportCh chan *report.Report) error
 		t.Cleanup(func() { fileutil.Cleanup(test.GeneratedCorpusDir) })
 	}
 
+	if test.CacheDir == "" {
+		test.CacheDir, err = os.MkdirTemp("", "cache")
+		require.NoError(t, err)
+		t.Cleanup(func() { fileutil.Cleanup(test.CacheDir) })
+	}
+
 	seedCorpusDir, err := os.MkdirTemp("", "seeds")
 	require.NoError(t, err)
 	t.Cleanup(func() { fileutil.Cleanup(seedCorpusDir) })
 
-	if test.RunsLimit != -1 {
-		// Limit the number of runs
-		test.EngineArgs = append(test.EngineArgs, fmt.Sprintf("-runs=%d", test.RunsLimit))
+	seedCorpusDirs := []string{seedCorpusDir}
+	for _, goFuzzCorpusDir := range test.GoFuzzCorpusDirs {
+		converted, err := os.MkdirTemp("", "go-fuzz-seeds")
+		require.NoError(t, err)
+		t.Cleanup(func() { fileutil.Cleanup(converted) })
+
+		err = gofuzzformat.ConvertSeedCorpusDir(goFuzzCorpusDir, converted)
+		require.NoError(t, err)
+		seedCorpusDirs = append(seedCorpusDirs, converted)
 	}
 
+	budget, err := libfuzzer.ParseRunBudget(test.RunBudget)
+	require.NoError(t, err)
+	test.EngineArgs = append(test.EngineArgs, budget.Args()...)
+
 	libfuzzerOptions := &libfuzzer.RunnerOptions{
+		CacheDir:           test.CacheDir,
 		EngineArgs:         test.EngineArgs,
 		EnvVars:            test.FuzzerEnv,
 		FuzzTarget:         test.FuzzTarget,
@@ -96,13 +158,14 @@ func (test *RunnerTest) Start(t *testing.T, reportCh chan *report.Report) error
 		GeneratedCorpusDir: test.GeneratedCorpusDir,
 		// To ease debugging, we write the output to stderr in addition
 		// to the test.LogOutput buffer
-		LogOutput:      io.MultiWriter(test.LogOutput, os.Stderr),
-		ProjectDir:     test.ProjectDir,
-		ReportHandler:  &ChannelPassthrough{ch: reportCh},
-		SeedCorpusDirs: []string{seedCorpusDir},
-		Timeout:        test.Timeout,
-		UseMinijail:    !test.DisableMinijail,
-		Verbose:        true,
+		LogOutput:       io.MultiWriter(test.LogOutput, os.Stderr),
+		MinimizeTimeout: test.MinimizeTimeout,
+		ProjectDir:      test.ProjectDir,
+		ReportHandler:   &ChannelPassthrough{ch: reportCh},
+		SeedCorpusDirs:  seedCorpusDirs,
+		Timeout:         test.Timeout,
+		UseMinijail:     !test.DisableMinijail,
+		Verbose:         true,
 	}
 	defer close(reportCh)
 
@@ -111,6 +174,24 @@ func (test *RunnerTest) Start(t *testing.T, reportCh chan *report.Report) error
 		return libfuzzerRunner.Run(context.Background())
 	}
 
+	if test.Engine == config.GO_NATIVE {
+		goNativeRunner := gonative.NewRunner(&gonative.RunnerOptions{
+			EngineArgs:         test.EngineArgs,
+			EnvVars:            test.FuzzerEnv,
+			FuzzTarget:         test.FuzzTarget,
+			FuzzTestArgs:       test.FuzzTestArgs,
+			GeneratedCorpusDir: test.GeneratedCorpusDir,
+			LogOutput:          io.MultiWriter(test.LogOutput, os.Stderr),
+			ProjectDir:         test.ProjectDir,
+			ReportHandler:      &ChannelPassthrough{ch: reportCh},
+			SeedCorpusDirs:     seedCorpusDirs,
+			Timeout:            test.Timeout,
+			UseMinijail:        !test.DisableMinijail,
+			Verbose:            true,
+		})
+		return goNativeRunner.Run(context.Background())
+	}
+
 	return fmt.Errorf("unknown fuzzing engine for test execution")
 }
 
@@ -128,11 +209,56 @@ func (test *RunnerTest) Run(t *testing.T) (string, []*report.Report) {
 		reports = append(reports, report)
 	}
 
+	test.reports = reports
+
 	return test.LogOutput.String(), reports
 }
 
+// RequireSeedCorpusNotEmpty asserts that the run promoted at least one
+// unique coverage-increasing input into GeneratedCorpusDir.
 func (test *RunnerTest) RequireSeedCorpusNotEmpty(t *testing.T) {
 	seeds, err := os.ReadDir(test.GeneratedCorpusDir)
 	require.NoError(t, err)
 	require.NotEmpty(t, seeds, "corpus directory is empty: %s", test.GeneratedCorpusDir)
 }
+
+// RequireCacheContains asserts that CacheDir holds an input whose
+// SHA-256 hash (hex-encoded) is hash, so that tests can verify a
+// reproducer persists across runs.
+func (test *RunnerTest) RequireCacheContains(t *testing.T, hash string) {
+	hashes, err := session.HashCorpus(test.CacheDir)
+	require.NoError(t, err)
+	require.Contains(t, hashes, hash, "cache directory %s does not contain an input with hash %s", test.CacheDir, hash)
+}
+
+// RequireMinimizedFinding asserts that the run produced a minimization
+// report (a report.MinimizedStatus report with a smaller reproducer than
+// the crashing input that triggered it). It must be called after Run.
+func (test *RunnerTest) RequireMinimizedFinding(t *testing.T) {
+	for _, r := range test.reports {
+		if r.Minimized != nil {
+			require.Less(t, len(r.Minimized.MinimizedInput), len(r.Minimized.OriginalInput),
+				"minimized input is not smaller than the original crashing input")
+			return
+		}
+	}
+	require.Fail(t, "no minimization report found", "reports: %+v", test.reports)
+}
+
+// RequireProgressMonotonic asserts that the run emitted at least one
+// progress report and that the reported execution counts never
+// decrease across the run. It must be called after Run.
+func (test *RunnerTest) RequireProgressMonotonic(t *testing.T) {
+	var last int64
+	seen := false
+	for _, r := range test.reports {
+		if r.Metric == nil {
+			continue
+		}
+		seen = true
+		require.GreaterOrEqual(t, r.Metric.TotalExecutions, last,
+			"progress report executions decreased: %d < %d", r.Metric.TotalExecutions, last)
+		last = r.Metric.TotalExecutions
+	}
+	require.True(t, seen, "no progress reports found")
+}
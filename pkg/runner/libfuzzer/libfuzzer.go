@@ -0,0 +1,720 @@
+// Package libfuzzer implements the runner which executes libFuzzer-based
+// fuzz targets and turns their output into *report.Report values.
+package libfuzzer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/pkg/sandbox"
+
+	_ "code-intelligence.com/cifuzz/pkg/minijail"    // register the minijail sandbox backend
+	_ "code-intelligence.com/cifuzz/pkg/nsjail"      // register the nsjail sandbox backend
+	_ "code-intelligence.com/cifuzz/pkg/sandboxexec" // register the sandbox-exec sandbox backend
+)
+
+// dedupeInterval controls how often the cache dir's contents are
+// deduped by SHA-256 into the generated corpus while a run is in
+// progress, so that the generated corpus stays up to date for tests or
+// tooling that inspect it before the run finishes.
+const dedupeInterval = 2 * time.Second
+
+// DefaultMinimizeTimeout is the standard post-crash minimization budget.
+// The runner does not apply it by itself: an unset (zero)
+// RunnerOptions.MinimizeTimeout disables minimization.
+const DefaultMinimizeTimeout = 60 * time.Second
+
+// ReportHandler receives reports emitted while a fuzz test is running.
+type ReportHandler interface {
+	Handle(report *report.Report) error
+}
+
+// RunnerOptions configures a single libFuzzer invocation.
+type RunnerOptions struct {
+	// CacheDir receives the inputs libFuzzer writes to disk during the
+	// run, duplicates included. It's passed to libFuzzer as
+	// `-artifact_prefix=<CacheDir>/`, never as a corpus directory;
+	// dedupeCache copies one copy of each unique input from it into
+	// GeneratedCorpusDir during and after the run.
+	CacheDir           string
+	Dictionary         string
+	EngineArgs         []string
+	EnvVars            []string
+	FuzzTarget         string
+	FuzzTestArgs       []string
+	GeneratedCorpusDir string
+	// Jobs is the number of parallel worker processes libFuzzer forks for
+	// this run, via `-fork=N -workers=N`. All workers share
+	// GeneratedCorpusDir, which libFuzzer itself keeps in sync between
+	// them. Jobs <= 1 runs libFuzzer in its regular single-process mode.
+	Jobs int
+	// KeepColor disables libFuzzer's own ANSI color codes when false,
+	// which is desired when --print-json is used so the JSON stream
+	// isn't interleaved with escape sequences.
+	KeepColor        bool
+	LogOutput        io.Writer
+	ProjectDir       string
+	ReadOnlyBindings []string
+	ReportHandler    ReportHandler
+	SeedCorpusDirs   []string
+	Timeout          time.Duration
+	UseMinijail      bool
+	Verbose          bool
+
+	// MutatorPlugin is the path to a libFuzzer mutator plugin
+	// ("libmutator.so") to LD_PRELOAD into the sandboxed fuzz target
+	// process, mirroring how ClusterFuzz's minijail helper exposes
+	// mutator plugins. It has no effect if UseMinijail is false.
+	MutatorPlugin string
+
+	// Backend selects which sandbox.New implementation to use when
+	// UseMinijail is set. The zero value, sandbox.BackendAuto, picks
+	// whichever backend suits runtime.GOOS.
+	Backend sandbox.Backend
+
+	// MinimizeTimeout bounds how long the post-crash minimization pass
+	// (`-minimize_crash=1`) is allowed to run once a crashing input has
+	// been found. 0, which is also the value when it's left unset,
+	// disables minimization; DefaultMinimizeTimeout is not filled in
+	// automatically.
+	MinimizeTimeout time.Duration
+
+	// MergeTargets, if non-empty, switches Run into libFuzzer's
+	// corpus-merge mode (`-merge=1`): instead of fuzzing, every input in
+	// MergeTargets is folded into GeneratedCorpusDir, keeping only the
+	// ones that add coverage over what's already there.
+	MergeTargets []string
+	// Minimize switches Run into corpus-merge mode the same way a
+	// non-empty MergeTargets does, additionally folding
+	// GeneratedCorpusDir's own existing inputs into the merge so that its
+	// redundant entries get pruned, mirroring libFuzzer's corpus
+	// minimization recipe.
+	Minimize bool
+
+	// Runtime identifies the language runtime FuzzTarget needs to be
+	// invoked through, mirroring build.Result.Runtime - e.g. "python" for
+	// an atheris wrapper script, which is run as "python3 <FuzzTarget>"
+	// rather than executed directly, since the bundle it was unpacked
+	// from may not have preserved its executable bit. Empty runs
+	// FuzzTarget directly, as before.
+	Runtime string
+}
+
+// command returns the argv FuzzTarget should be invoked with, prefixed
+// with an interpreter if Runtime requires one.
+func (r *Runner) command() []string {
+	switch r.Runtime {
+	case "python":
+		return []string{"python3", r.FuzzTarget}
+	default:
+		return []string{r.FuzzTarget}
+	}
+}
+
+// resolveArgv0 resolves args[0] to an absolute path via exec.LookPath if
+// it's a bare command name rather than a path, returning args unchanged
+// otherwise. This matters for sandboxed runs: unlike exec.CommandContext,
+// the minijail/nsjail backends' use of filepath.EvalSymlinks doesn't
+// consult $PATH, so a bare name like command's "python3" would otherwise
+// fail to resolve once sandboxed.
+func resolveArgv0(args []string) ([]string, error) {
+	if strings.ContainsRune(args[0], filepath.Separator) {
+		return args, nil
+	}
+	resolved, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append([]string{resolved}, args[1:]...), nil
+}
+
+func (opts *RunnerOptions) Validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget is not set")
+	}
+	if opts.GeneratedCorpusDir == "" {
+		return errors.New("GeneratedCorpusDir is not set")
+	}
+	if opts.CacheDir == "" {
+		return errors.New("CacheDir is not set")
+	}
+	return nil
+}
+
+type Runner struct {
+	*RunnerOptions
+
+	// seenFindings dedupes findings across the fork workers started for
+	// Jobs > 1, keyed by a hash of the finding's sanitizer type and
+	// summary, since independent workers can otherwise report the same
+	// crash more than once.
+	seenFindings map[string]bool
+}
+
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts}
+}
+
+// args builds the libFuzzer command line, in the order libFuzzer expects:
+// flags first, then the corpus directories it should read from and write
+// new interesting inputs to.
+func (r *Runner) args() []string {
+	args := []string{
+		"-error_exitcode=" + fmt.Sprint(runner.LibFuzzerErrorExitCode),
+		"-artifact_prefix=" + r.CacheDir + string(filepath.Separator),
+	}
+	if r.Dictionary != "" {
+		args = append(args, "-dict="+r.Dictionary)
+	}
+	if r.Timeout != 0 {
+		args = append(args, fmt.Sprintf("-max_total_time=%d", int(r.Timeout.Seconds())))
+	}
+	if r.Jobs > 1 {
+		args = append(args, fmt.Sprintf("-fork=%d", r.Jobs), fmt.Sprintf("-workers=%d", r.Jobs))
+	}
+	if !r.KeepColor {
+		args = append(args, "-color=0")
+	}
+	args = append(args, r.EngineArgs...)
+	args = append(args, r.FuzzTestArgs...)
+	args = append(args, r.GeneratedCorpusDir)
+	args = append(args, r.SeedCorpusDirs...)
+	return args
+}
+
+// Run starts the fuzz target and streams its output into reports until it
+// exits or the context is canceled. If MergeTargets is non-empty or
+// Minimize is set, it runs libFuzzer's corpus-merge mode instead of the
+// regular fuzzing loop; see Merge.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	if len(r.MergeTargets) > 0 || r.Minimize {
+		return r.Merge(ctx)
+	}
+
+	err = os.MkdirAll(r.CacheDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	args := append(r.command(), r.args()...)
+
+	if r.UseMinijail {
+		args, err = resolveArgv0(args)
+		if err != nil {
+			return err
+		}
+		sb, err := sandbox.New(&sandbox.Options{
+			Args:      args,
+			OutputDir: r.GeneratedCorpusDir,
+			Bindings: []*sandbox.Binding{
+				{Source: r.CacheDir, Writable: sandbox.ReadWrite},
+			},
+			MutatorPlugin: r.MutatorPlugin,
+			Backend:       r.Backend,
+		})
+		if err != nil {
+			return err
+		}
+		defer sb.Cleanup()
+		args = sb.Args()
+		r.EnvVars = sandbox.MergeEnv(r.EnvVars, sb.Env())
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = r.EnvVars
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cmd.Stdout = cmd.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	dedupeDone := make(chan struct{})
+	go r.dedupeCachePeriodically(ctx, dedupeDone)
+	defer func() {
+		close(dedupeDone)
+		r.dedupeCache()
+	}()
+
+	tail, reported, err := r.streamOutput(ctx, stderr)
+	if err != nil {
+		return err
+	}
+
+	return classifyRunError(cmd.Wait(), tail, reported)
+}
+
+// classifyRunError turns the error exec.Cmd.Wait returns for a libFuzzer
+// run into nil for a clean exit or one streamOutput already turned into
+// a report.Report, or a *runner.UnexpectedExitError, carrying tail,
+// for every other exit code - including OutcomeCrash/OutcomeSetupError
+// codes such as libFuzzer's legacy exit code 1, which have no SUMMARY
+// line for streamOutput to have reported as a finding and so would
+// otherwise be silently treated as success.
+func classifyRunError(err error, tail []string, reported bool) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return errors.WithStack(err)
+	}
+
+	if reported {
+		return nil
+	}
+
+	code := exitErr.ExitCode()
+	return &runner.UnexpectedExitError{Kind: runner.KindLibFuzzer, Code: code, StderrTail: tail}
+}
+
+// Merge runs the fuzz target with `-merge=1`, folding every corpus in
+// MergeTargets (and, if Minimize is set, GeneratedCorpusDir's own
+// existing inputs) into GeneratedCorpusDir, keeping only the inputs that
+// increase coverage over what's already there. The merge is performed
+// into a fresh directory first and then moved into place, so that a
+// crash or failure partway through never leaves GeneratedCorpusDir in a
+// half-merged state.
+func (r *Runner) Merge(ctx context.Context) error {
+	mergeDir, err := os.MkdirTemp("", "libfuzzer-merge")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(mergeDir)
+
+	sources := append([]string{}, r.MergeTargets...)
+	if r.Minimize {
+		sources = append(sources, r.GeneratedCorpusDir)
+	}
+
+	mergeArgs := []string{
+		"-error_exitcode=" + fmt.Sprint(runner.LibFuzzerErrorExitCode),
+		"-merge=1",
+	}
+	if !r.KeepColor {
+		mergeArgs = append(mergeArgs, "-color=0")
+	}
+	mergeArgs = append(mergeArgs, r.EngineArgs...)
+	mergeArgs = append(mergeArgs, mergeDir)
+	mergeArgs = append(mergeArgs, sources...)
+
+	args := append(r.command(), mergeArgs...)
+
+	if r.UseMinijail {
+		bindings := []*sandbox.Binding{
+			{Source: mergeDir, Writable: sandbox.ReadWrite},
+		}
+		for _, source := range sources {
+			bindings = append(bindings, &sandbox.Binding{Source: source, Writable: sandbox.ReadOnly})
+		}
+		args, err = resolveArgv0(args)
+		if err != nil {
+			return err
+		}
+		sb, err := sandbox.New(&sandbox.Options{
+			Args:          args,
+			Bindings:      bindings,
+			MutatorPlugin: r.MutatorPlugin,
+			Backend:       r.Backend,
+		})
+		if err != nil {
+			return err
+		}
+		defer sb.Cleanup()
+		args = sb.Args()
+		r.EnvVars = sandbox.MergeEnv(r.EnvVars, sb.Env())
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = r.EnvVars
+	output, err := cmd.CombinedOutput()
+	if r.LogOutput != nil {
+		_, _ = r.LogOutput.Write(output)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return replaceCorpus(mergeDir, r.GeneratedCorpusDir)
+}
+
+// replaceCorpus moves every file out of mergeDir and into dst, replacing
+// dst's previous contents.
+func replaceCorpus(mergeDir, dst string) error {
+	entries, err := os.ReadDir(mergeDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.RemoveAll(dst)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(dst, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range entries {
+		err = renameOrCopy(filepath.Join(mergeDir, entry.Name()), filepath.Join(dst, entry.Name()))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// renameOrCopy moves src to dst like os.Rename, but falls back to
+// copying src to dst and then removing src if they're on different
+// filesystems (os.Rename fails with EXDEV in that case) - which is the
+// common case for mergeDir, which lives under os.TempDir(), and dst,
+// which is the project's own generated corpus directory.
+func renameOrCopy(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) || linkErr.Err != syscall.EXDEV {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := in.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// crashFileLine matches libFuzzer's message naming the artifact it wrote
+// for a crashing input, e.g.:
+//
+//	artifact_prefix='./'; Test unit written to ./crash-1234deadbeef
+var crashFileLine = regexp.MustCompile(`Test unit written to (\S+)`)
+
+// summaryLine matches a sanitizer's one-line summary of a finding, e.g.:
+//
+//	SUMMARY: AddressSanitizer: heap-buffer-overflow ...
+var summaryLine = regexp.MustCompile(`^SUMMARY: (\w+): (.+)$`)
+
+// progressLine matches libFuzzer's periodic status lines, e.g.:
+//
+//	#4096   REDUCE cov: 15 ft: 16 corp: 2/2b lim: 9 exec/s: 585 rss: 26Mb
+var progressLine = regexp.MustCompile(
+	`^#(\d+)\s+(?:pulse|NEW|REDUCE|INITED|DONE)\s+cov: (\d+) ft: (\d+) corp: (\d+)/\S+ lim: \d+ exec/s: (\d+)`)
+
+// progressInterval bounds how often a progress report is emitted, since
+// libFuzzer's #NNN status lines can arrive much more often than that.
+const progressInterval = 1 * time.Second
+
+// exitErrorTailLines bounds how many of the fuzz target's most recent
+// stderr lines streamOutput keeps around for a *runner.UnexpectedExitError,
+// in case the run exits non-zero without a finding having been reported.
+const exitErrorTailLines = 20
+
+// streamOutput tees stderr to r.LogOutput while scanning it for progress
+// metrics and sanitizer findings. The returned reported flag tells
+// classifyRunError whether a finding was already turned into a
+// report.Report here, so it doesn't also report the run's exit code as
+// an *runner.UnexpectedExitError.
+func (r *Runner) streamOutput(ctx context.Context, stderr io.Reader) (tail []string, reported bool, err error) {
+	scanner := bufio.NewScanner(stderr)
+	var crashingInput string
+	var logs []string
+	start := time.Now()
+	var lastProgress time.Time
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if r.LogOutput != nil {
+			fmt.Fprintln(r.LogOutput, line)
+		}
+		logs = append(logs, line)
+
+		tail = append(tail, line)
+		if len(tail) > exitErrorTailLines {
+			tail = tail[len(tail)-exitErrorTailLines:]
+		}
+
+		if match := crashFileLine.FindStringSubmatch(line); match != nil {
+			crashingInput = match[1]
+		}
+
+		if match := progressLine.FindStringSubmatch(line); match != nil {
+			now := time.Now()
+			if lastProgress.IsZero() || now.Sub(lastProgress) >= progressInterval {
+				lastProgress = now
+				metric, err := parseProgressMetric(match, now, now.Sub(start))
+				if err != nil {
+					return nil, false, err
+				}
+				if err := r.ReportHandler.Handle(report.ReportProgress(metric)); err != nil {
+					return nil, false, err
+				}
+			}
+		}
+
+		if match := summaryLine.FindStringSubmatch(line); match != nil {
+			reported = true
+			if !r.findingSeen(match[1], match[2]) {
+				err := r.ReportHandler.Handle(&report.Report{
+					Status: report.FailedStatus,
+					Finding: &report.Finding{
+						Name:      match[1],
+						Type:      match[1],
+						Details:   match[2],
+						InputFile: crashingInput,
+						Logs:      logs,
+					},
+				})
+				if err != nil {
+					return nil, false, err
+				}
+
+				if crashingInput != "" && r.MinimizeTimeout != 0 {
+					err = r.minimizeCrash(ctx, crashingInput)
+					if err != nil {
+						return nil, false, err
+					}
+				}
+			}
+
+			logs = nil
+			crashingInput = ""
+		}
+	}
+
+	return tail, reported, errors.WithStack(scanner.Err())
+}
+
+// parseProgressMetric converts a progressLine match into a
+// report.Metric, attributing timestamp and elapsed to now.
+func parseProgressMetric(match []string, now time.Time, elapsed time.Duration) (*report.Metric, error) {
+	execs, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	edges, err := strconv.ParseInt(match[2], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	features, err := strconv.ParseInt(match[3], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	corpusSize, err := strconv.ParseInt(match[4], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	execsPerSec, err := strconv.ParseInt(match[5], 10, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &report.Metric{
+		Timestamp:           now,
+		Elapsed:             elapsed,
+		ExecutionsPerSecond: int32(execsPerSec),
+		Features:            int32(features),
+		CorpusSize:          int32(corpusSize),
+		TotalExecutions:     execs,
+		Edges:               int32(edges),
+	}, nil
+}
+
+// minimizedFileLine matches libFuzzer's message naming the file it wrote
+// the smallest reproducer found so far to during a `-minimize_crash=1`
+// run, e.g.:
+//
+//	CRASH_MIN: minimizing crash input: size 1234
+//	...
+//	Saved minimized crash input to: ./minimized-from-1234deadbeef
+var minimizedFileLine = regexp.MustCompile(`Saved minimized crash input to: (\S+)`)
+
+// minimizeCrash re-invokes the fuzz target with `-minimize_crash=1`
+// against the given crashing input, bounded by MinimizeTimeout, and
+// emits a report.Report describing the smaller reproducer libFuzzer
+// found, if any.
+func (r *Runner) minimizeCrash(ctx context.Context, crashingInput string) error {
+	original, err := os.ReadFile(crashingInput)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	args := append(r.command(),
+		"-minimize_crash=1",
+		"-runs=-1",
+		fmt.Sprintf("-max_total_time=%d", int(r.MinimizeTimeout.Seconds())),
+		crashingInput,
+	)
+
+	crashDir := filepath.Dir(crashingInput)
+
+	if r.UseMinijail {
+		args, err = resolveArgv0(args)
+		if err != nil {
+			return err
+		}
+		sb, err := sandbox.New(&sandbox.Options{
+			Args: args,
+			Bindings: []*sandbox.Binding{
+				{Source: crashDir, Writable: sandbox.ReadWrite},
+			},
+			MutatorPlugin: r.MutatorPlugin,
+			Backend:       r.Backend,
+		})
+		if err != nil {
+			return err
+		}
+		defer sb.Cleanup()
+		args = sb.Args()
+		r.EnvVars = sandbox.MergeEnv(r.EnvVars, sb.Env())
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = r.EnvVars
+	cmd.Dir = crashDir
+
+	output, err := cmd.CombinedOutput()
+	if r.LogOutput != nil {
+		r.LogOutput.Write(output)
+	}
+	// libFuzzer's minimization loop exits non-zero once it can no longer
+	// make progress within the time budget; that's expected, not an
+	// error, as long as it produced output we can parse.
+	if err != nil && len(output) == 0 {
+		return errors.WithStack(err)
+	}
+
+	match := minimizedFileLine.FindStringSubmatch(string(output))
+	if match == nil {
+		// libFuzzer wasn't able to shrink the input within the budget.
+		return nil
+	}
+
+	minimizedPath := match[1]
+	if !filepath.IsAbs(minimizedPath) {
+		minimizedPath = filepath.Join(cmd.Dir, minimizedPath)
+	}
+	minimized, err := os.ReadFile(minimizedPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return r.ReportHandler.Handle(&report.Report{
+		Status: report.MinimizedStatus,
+		Minimized: &report.Minimized{
+			OriginalInput:  original,
+			MinimizedInput: minimized,
+			OriginalSize:   len(original),
+			MinimizedSize:  len(minimized),
+		},
+	})
+}
+
+// dedupeCachePeriodically runs dedupeCache every dedupeInterval until
+// done is closed or ctx is canceled.
+func (r *Runner) dedupeCachePeriodically(ctx context.Context, done <-chan struct{}) {
+	ticker := time.NewTicker(dedupeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.dedupeCache()
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dedupeCache copies every input libFuzzer has written to CacheDir into
+// GeneratedCorpusDir, named after its SHA-256 hash, skipping inputs
+// already present there. CacheDir retains duplicates and near-misses;
+// GeneratedCorpusDir ends up holding one copy of each unique input.
+func (r *Runner) dedupeCache() {
+	entries, err := os.ReadDir(r.CacheDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(r.CacheDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		hash := sha256.Sum256(content)
+		dstPath := filepath.Join(r.GeneratedCorpusDir, hex.EncodeToString(hash[:]))
+		if _, err := os.Stat(dstPath); err == nil {
+			continue
+		}
+		_ = os.WriteFile(dstPath, content, 0o644)
+	}
+}
+
+// findingSeen reports whether a finding with this sanitizer type and
+// summary has already been reported during this run, recording it as
+// seen otherwise. With Jobs > 1, libFuzzer's fork workers run
+// independently and can each hit and report the same crash.
+func (r *Runner) findingSeen(findingType, details string) bool {
+	if r.seenFindings == nil {
+		r.seenFindings = map[string]bool{}
+	}
+	hash := sha256.Sum256([]byte(findingType + "\x00" + details))
+	key := hex.EncodeToString(hash[:])
+	if r.seenFindings[key] {
+		return true
+	}
+	r.seenFindings[key] = true
+	return false
+}
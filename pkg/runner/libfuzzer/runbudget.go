@@ -0,0 +1,55 @@
+package libfuzzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RunBudget bounds how long or how many executions a libFuzzer run is
+// allowed to perform, mirroring Go's durationOrCountFlag (used by `go
+// test -fuzztime`): either a bare run count suffixed with "x" (e.g.
+// "5000x") or a time.Duration string (e.g. "30s"). The zero value means
+// no limit.
+type RunBudget struct {
+	Runs     int64
+	Duration time.Duration
+}
+
+// ParseRunBudget parses s into a RunBudget. An empty string returns the
+// zero value (no limit).
+func ParseRunBudget(s string) (RunBudget, error) {
+	if s == "" {
+		return RunBudget{}, nil
+	}
+
+	if strings.HasSuffix(s, "x") {
+		runs, err := strconv.ParseInt(strings.TrimSuffix(s, "x"), 10, 64)
+		if err != nil {
+			return RunBudget{}, errors.Wrapf(err, "invalid run count %q", s)
+		}
+		return RunBudget{Runs: runs}, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return RunBudget{}, errors.Wrapf(err, "invalid run budget %q", s)
+	}
+	return RunBudget{Duration: d}, nil
+}
+
+// Args returns the libFuzzer flag expressing this budget, or nil if the
+// budget is unset.
+func (b RunBudget) Args() []string {
+	switch {
+	case b.Runs != 0:
+		return []string{fmt.Sprintf("-runs=%d", b.Runs)}
+	case b.Duration != 0:
+		return []string{fmt.Sprintf("-max_total_time=%d", int(b.Duration.Seconds()))}
+	default:
+		return nil
+	}
+}
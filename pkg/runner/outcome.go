@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind identifies which mode a fuzz target was run in, since the same
+// exit code can mean different things depending on it - e.g. exit code 1
+// is libFuzzer's generic "something went wrong" during fuzzing and
+// classifies as OutcomeCrash there, but it isn't an expected exit code
+// of a KindReplay run at all and classifies as OutcomeUnexpected.
+type Kind int
+
+const (
+	// KindLibFuzzer is a libFuzzer (or Jazzer, which mimics libFuzzer's
+	// exit codes) fuzzing run.
+	KindLibFuzzer Kind = iota
+	// KindReplay is a single input being replayed against a fuzz target
+	// outside of the regular fuzzing loop, e.g. to reproduce or minimize
+	// a finding.
+	KindReplay
+)
+
+// Outcome classifies the result of a fuzzer run, so that callers can
+// switch on it instead of comparing raw exit codes themselves.
+type Outcome int
+
+const (
+	// OutcomeOK means the run completed without finding anything.
+	OutcomeOK Outcome = iota
+	// OutcomeCrash means the fuzz target crashed (e.g. with a segfault)
+	// rather than a sanitizer reporting an error.
+	OutcomeCrash
+	// OutcomeSanitizerError means a sanitizer (ASan, UBSan, Jazzer's
+	// finding detector, ...) reported an error.
+	OutcomeSanitizerError
+	// OutcomeOOM means the run was killed for exceeding its memory limit.
+	OutcomeOOM
+	// OutcomeTimeout means a single input took longer than the
+	// configured per-input timeout to execute.
+	OutcomeTimeout
+	// OutcomeSetupError means the fuzz target exited before fuzzing
+	// could start at all, e.g. because of a build-system or
+	// classpath/library-loading failure. This is deliberately distinct
+	// from OutcomeCrash and OutcomeSanitizerError so that a broken build
+	// isn't misreported as a finding.
+	OutcomeSetupError
+	// OutcomeUnexpected means the exit code isn't one ClassifyExitCode
+	// knows how to interpret for the given Kind.
+	OutcomeUnexpected
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeOK:
+		return "ok"
+	case OutcomeCrash:
+		return "crash"
+	case OutcomeSanitizerError:
+		return "sanitizer error"
+	case OutcomeOOM:
+		return "out of memory"
+	case OutcomeTimeout:
+		return "timeout"
+	case OutcomeSetupError:
+		return "setup error"
+	default:
+		return "unexpected"
+	}
+}
+
+// ExpectedExitCodes lists the exit codes ClassifyExitCode can map to a
+// meaningful Outcome for runnerKind; any other exit code classifies as
+// OutcomeUnexpected.
+func ExpectedExitCodes(runnerKind Kind) []int {
+	switch runnerKind {
+	case KindReplay:
+		// Replaying a single input only ever either passes or triggers a
+		// finding; there's no fork-server/OOM/timeout machinery in play
+		// the way there is during fuzzing.
+		return []int{0, LibFuzzerErrorExitCode, SanitizerErrorExitCode}
+	default:
+		return []int{0, DeprecatedSanitizerErrorExitCode, LibFuzzerTimeoutExitCode, LibFuzzerOOMExitCode, LibFuzzerErrorExitCode, SanitizerErrorExitCode}
+	}
+}
+
+// ClassifyExitCode maps a fuzz target's exit code to an Outcome, given
+// the Kind of run it exited from.
+func ClassifyExitCode(code int, runnerKind Kind) Outcome {
+	switch runnerKind {
+	case KindReplay:
+		switch code {
+		case 0:
+			return OutcomeOK
+		case LibFuzzerErrorExitCode, SanitizerErrorExitCode:
+			return OutcomeSanitizerError
+		default:
+			return OutcomeUnexpected
+		}
+	default:
+		switch code {
+		case 0:
+			return OutcomeOK
+		case LibFuzzerErrorExitCode, SanitizerErrorExitCode:
+			return OutcomeSanitizerError
+		case LibFuzzerOOMExitCode:
+			return OutcomeOOM
+		case LibFuzzerTimeoutExitCode:
+			return OutcomeTimeout
+		case DeprecatedSanitizerErrorExitCode:
+			// Exit code 1 is ambiguous: it's both libFuzzer's catch-all
+			// for an uncaught crash and what a target exits with when it
+			// fails before fuzzing even starts (e.g. jazzer_driver
+			// failing to resolve the classpath). Since there's no
+			// SUMMARY line to tell the two apart here, report it as a
+			// crash rather than guessing it's a sanitizer finding.
+			return OutcomeCrash
+		default:
+			return OutcomeUnexpected
+		}
+	}
+}
+
+// UnexpectedExitError is returned when a fuzz target exits non-zero and
+// no reported finding accounts for it - whether or not ClassifyExitCode
+// recognizes the code - so the CLI can report "fuzzer crashed for an
+// unknown reason" instead of silently treating it as - or failing to
+// treat it as - a finding.
+type UnexpectedExitError struct {
+	Kind Kind
+	Code int
+	// StderrTail holds the last lines of the fuzz target's stderr, to
+	// help diagnose why it exited the way it did.
+	StderrTail []string
+}
+
+func (e *UnexpectedExitError) Error() string {
+	msg := fmt.Sprintf("fuzzer exited with unexpected code %d", e.Code)
+	if len(e.StderrTail) > 0 {
+		msg += fmt.Sprintf("\nLast output:\n%s", strings.Join(e.StderrTail, "\n"))
+	}
+	return msg
+}
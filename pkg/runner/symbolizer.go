@@ -0,0 +1,231 @@
+package runner
+
+// NOTE: this tree has no internal/cmd/findings package and no run-report
+// generator for Symbolize to be wired into (neither is present in this
+// snapshot), so this file implements the self-contained piece the
+// request centers on - Symbolizer itself - in a shape that a
+// `findings`/report-generator command could call once it exists, the
+// same way jazzer_bundler_test.go outlines a bundler that isn't wired up
+// yet.
+
+import (
+	"bufio"
+	"container/list"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// unsymbolizedFrame matches an ASan/libFuzzer stack frame that
+// llvm-symbolizer hasn't resolved yet, e.g.
+//
+//	#3 0x55d1a2b3c4d5 in ?? /path/to/binary+0x1c4d5
+var unsymbolizedFrame = regexp.MustCompile(`^(\s*#\d+\s+)(0x[0-9a-fA-F]+)\s+in\s+\?\?\s+(\S+)\+(0x[0-9a-fA-F]+)(.*)$`)
+
+// asanSummaryLine matches the "SUMMARY: AddressSanitizer: ..." line
+// AddressSanitizer prints at the end of a report.
+var asanSummaryLine = regexp.MustCompile(`^SUMMARY: AddressSanitizer: `)
+
+// jazzerFrame matches a Jazzer-style (JVM) stack trace frame, which
+// llvm-symbolizer can't resolve and Symbolize instead passes through
+// unchanged, e.g. "\tat com.example.Target.fuzzerTestOneInput(Target.java:12)".
+var jazzerFrame = regexp.MustCompile(`^\s*at [\w.$]+\([\w.]+(:\d+)?\)$`)
+
+// symbolizerCacheSize bounds the number of resolved (binary, offset)
+// frames Symbolizer keeps in memory.
+const symbolizerCacheSize = 4096
+
+type frameKey struct {
+	binary string
+	offset string
+}
+
+// Symbolizer streams fuzzer output, recognizing stack frames that
+// weren't resolved at crash time (typically because ASAN_SYMBOLIZER_PATH
+// wasn't set when the crash was recorded) and resolves them via a
+// long-lived llvm-symbolizer subprocess. It only supports resolving
+// against the binary path recorded in the crash log itself - ASan's
+// frame lines don't carry a build ID, so there's no way to match a
+// frame to a debug binary stored elsewhere.
+//
+// A Symbolizer is only good for one Symbolize call's binaries: it starts
+// llvm-symbolizer lazily and keeps it running for the lifetime of the
+// Symbolizer to amortize process start-up cost across many frames.
+type Symbolizer struct {
+	// LLVMSymbolizerPath is the path to the llvm-symbolizer binary to
+	// use. Defaults to "llvm-symbolizer" (resolved via PATH) when empty.
+	LLVMSymbolizerPath string
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	cache   map[frameKey]*list.Element
+	lru     *list.List
+	started bool
+}
+
+type cacheEntry struct {
+	key    frameKey
+	result string
+}
+
+// Symbolize reads fuzzer output line-by-line from in, resolving any
+// unsymbolized ASan/libFuzzer frame it recognizes via llvm-symbolizer and
+// writing every line, resolved or not, to out. Jazzer-style Java frames
+// and any other line it doesn't recognize are passed through unchanged.
+func (s *Symbolizer) Symbolize(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case unsymbolizedFrame.MatchString(line):
+			resolved, err := s.resolveFrame(line)
+			if err != nil {
+				// Fall through and print the line unresolved rather than
+				// aborting the whole stream over one bad frame.
+				resolved = line
+			}
+			line = resolved
+		case asanSummaryLine.MatchString(line), jazzerFrame.MatchString(line):
+			// Nothing to resolve, pass through as-is.
+		}
+
+		_, err := fmt.Fprintln(out, line)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+func (s *Symbolizer) resolveFrame(line string) (string, error) {
+	m := unsymbolizedFrame.FindStringSubmatch(line)
+	if m == nil {
+		return line, nil
+	}
+	prefix, addr, binary, offset, suffix := m[1], m[2], m[3], m[4], m[5]
+
+	frame, err := s.lookup(binary, offset)
+	if err != nil {
+		return line, err
+	}
+
+	return fmt.Sprintf("%s%s in %s %s", prefix, addr, frame, suffix), nil
+}
+
+func (s *Symbolizer) lookup(binary, offset string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := frameKey{binary: binary, offset: offset}
+	if s.cache != nil {
+		if elem, ok := s.cache[key]; ok {
+			s.lru.MoveToFront(elem)
+			return elem.Value.(*cacheEntry).result, nil
+		}
+	}
+
+	result, err := s.query(binary, offset)
+	if err != nil {
+		return "", err
+	}
+
+	s.store(key, result)
+	return result, nil
+}
+
+func (s *Symbolizer) store(key frameKey, result string) {
+	if s.cache == nil {
+		s.cache = make(map[frameKey]*list.Element)
+		s.lru = list.New()
+	}
+	s.cache[key] = s.lru.PushFront(&cacheEntry{key: key, result: result})
+	for s.lru.Len() > symbolizerCacheSize {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.cache, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// query resolves a single (binary, offset) pair via the long-lived
+// llvm-symbolizer subprocess, starting it if this is the first lookup.
+// DWARF (native ELF/Mach-O) and PDB binaries are both handled by passing
+// "--relativenames", which llvm-symbolizer supports for either format.
+func (s *Symbolizer) query(binary, offset string) (string, error) {
+	err := s.ensureStarted()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = fmt.Fprintf(s.stdin, "%s %s\n", binary, offset)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	function, err := s.stdout.ReadString('\n')
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	location, err := s.stdout.ReadString('\n')
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	// llvm-symbolizer prints a blank line after each resolved frame to
+	// separate it from the next query's output.
+	_, err = s.stdout.ReadString('\n')
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return fmt.Sprintf("%s %s", strings.TrimSpace(function), strings.TrimSpace(location)), nil
+}
+
+func (s *Symbolizer) ensureStarted() error {
+	if s.started {
+		return nil
+	}
+
+	path := s.LLVMSymbolizerPath
+	if path == "" {
+		path = "llvm-symbolizer"
+	}
+
+	cmd := exec.Command(path, "--relativenames")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.cmd = cmd
+	s.stdin = stdin
+	s.stdout = bufio.NewReader(stdout)
+	s.started = true
+	return nil
+}
+
+// Close shuts down the llvm-symbolizer subprocess, if one was started.
+func (s *Symbolizer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		return nil
+	}
+	_ = s.stdin.Close()
+	return errors.WithStack(s.cmd.Wait())
+}
@@ -0,0 +1,427 @@
+// Package sandbox defines the backend-agnostic types fuzz target runners
+// use to sandbox a fuzzing or replay run, plus a registry that lets a
+// concrete implementation (pkg/minijail, pkg/nsjail) plug itself in
+// without this package importing either.
+//
+// Runners used to construct a *minijail.Minijail directly; now they call
+// sandbox.New, which picks a Backend (explicitly via Options.Backend, a
+// --sandbox=<mode> flag parsed with ParseBackend, or the
+// CIFUZZ_SANDBOX=minijail|nsjail|sandbox-exec|none environment variable)
+// and dispatches to whichever implementation registered for it. This is the
+// same blank-import-and-register pattern database/sql uses for drivers,
+// which fits here because sandbox can't import minijail/nsjail without
+// creating an import cycle (they both need the types defined below).
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WritableOption controls whether a Binding is mounted read-only or
+// read-write inside the sandbox.
+type WritableOption int
+
+const (
+	ReadOnly WritableOption = iota
+	ReadWrite
+)
+
+// Binding is a path from outside the sandbox that should be made
+// available inside it, either as-is (Target == "") or remapped to
+// Target. Backends render Bindings into their own config format (e.g.
+// minijail's "bind-mount=" lines, nsjail's "mount { ... }" stanzas).
+type Binding struct {
+	Source   string
+	Target   string
+	Writable WritableOption
+}
+
+// ActionKind is the action taken when a filtered syscall is invoked.
+type ActionKind int
+
+const (
+	actionAllow ActionKind = iota
+	actionErrno
+	actionKill
+	actionTrace
+	actionLog
+)
+
+// Action is what happens when a filtered syscall is invoked. Allow, Kill,
+// Trace and Log are the fixed actions; use Errno(n) for an action that
+// makes the syscall fail with the given errno instead of running.
+type Action struct {
+	kind  ActionKind
+	errno int
+}
+
+var (
+	// Allow lets the syscall run normally.
+	Allow = Action{kind: actionAllow}
+	// Kill terminates the process immediately.
+	Kill = Action{kind: actionKill}
+	// Trace reports the syscall via ptrace instead of running it, for
+	// use while developing a policy.
+	Trace = Action{kind: actionTrace}
+	// Log lets the syscall run but logs it, for use while developing a
+	// policy.
+	Log = Action{kind: actionLog}
+)
+
+// Errno makes the filtered syscall fail with the given errno instead of
+// running.
+func Errno(errno int) Action {
+	return Action{kind: actionErrno, errno: errno}
+}
+
+// Kind returns which fixed action a is, for backends rendering their own
+// policy format from it.
+func (a Action) Kind() ActionKind {
+	return a.kind
+}
+
+// Errno returns the errno an actionErrno Action fails the syscall with.
+// It's meaningless for any other ActionKind.
+func (a Action) Errno() int {
+	return a.errno
+}
+
+// SyscallRule is one entry of a seccomp-BPF policy: what Action to take
+// when Name is called.
+type SyscallRule struct {
+	Name   string
+	Action Action
+}
+
+// SeccompPolicy configures the seccomp-BPF filter the sandboxed process
+// runs under. Set either Path, to use an existing compiled BPF program or
+// policy source file as-is, or Rules, to have the backend render its own
+// policy format from the given allow-list.
+type SeccompPolicy struct {
+	Path  string
+	Rules []SyscallRule
+}
+
+// DefaultFuzzerSeccompPolicy is a curated allow-list covering the
+// syscalls libFuzzer- and AFL-based fuzz targets make under glibc on
+// Linux/x86-64. It's a starting point for a SeccompPolicy.Rules value,
+// not a guarantee that every fuzz target runs under it unmodified -
+// targets using uncommon syscalls will need their own rules added on
+// top. Any syscall not listed here falls back to the backend's default
+// seccomp action, which kills the process, so that a fuzz target
+// breaking the policy fails loudly instead of silently succeeding.
+var DefaultFuzzerSeccompPolicy = []SyscallRule{
+	{Name: "read", Action: Allow},
+	{Name: "write", Action: Allow},
+	{Name: "open", Action: Allow},
+	{Name: "openat", Action: Allow},
+	{Name: "close", Action: Allow},
+	{Name: "fstat", Action: Allow},
+	{Name: "stat", Action: Allow},
+	{Name: "lstat", Action: Allow},
+	{Name: "newfstatat", Action: Allow},
+	{Name: "mmap", Action: Allow},
+	{Name: "mprotect", Action: Allow},
+	{Name: "munmap", Action: Allow},
+	{Name: "brk", Action: Allow},
+	{Name: "rt_sigaction", Action: Allow},
+	{Name: "rt_sigprocmask", Action: Allow},
+	{Name: "rt_sigreturn", Action: Allow},
+	{Name: "sigaltstack", Action: Allow},
+	{Name: "ioctl", Action: Allow},
+	{Name: "access", Action: Allow},
+	{Name: "pipe", Action: Allow},
+	{Name: "pipe2", Action: Allow},
+	{Name: "dup", Action: Allow},
+	{Name: "dup2", Action: Allow},
+	{Name: "clone", Action: Allow},
+	{Name: "fork", Action: Allow},
+	{Name: "execve", Action: Allow},
+	{Name: "exit", Action: Allow},
+	{Name: "exit_group", Action: Allow},
+	{Name: "wait4", Action: Allow},
+	{Name: "futex", Action: Allow},
+	{Name: "lseek", Action: Allow},
+	{Name: "getrandom", Action: Allow},
+	{Name: "getpid", Action: Allow},
+	{Name: "gettid", Action: Allow},
+	{Name: "getrlimit", Action: Allow},
+	{Name: "setrlimit", Action: Allow},
+	{Name: "prlimit64", Action: Allow},
+	{Name: "arch_prctl", Action: Allow},
+	{Name: "set_tid_address", Action: Allow},
+	{Name: "set_robust_list", Action: Allow},
+	{Name: "readlink", Action: Allow},
+	{Name: "madvise", Action: Allow},
+	{Name: "uname", Action: Allow},
+	{Name: "sched_getaffinity", Action: Allow},
+	{Name: "clock_gettime", Action: Allow},
+	{Name: "getcwd", Action: Allow},
+}
+
+// Backend selects which sandboxing mechanism New uses.
+type Backend int
+
+const (
+	// BackendAuto picks the first registered backend for runtime.GOOS
+	// (minijail, then nsjail on Linux; sandbox-exec on macOS), falling
+	// back to no sandboxing at all. It's the zero value
+	// so that an Options literal which doesn't set Backend keeps
+	// today's "sandbox if we can, otherwise don't" behavior.
+	BackendAuto Backend = iota
+	BackendMinijail
+	BackendNsjail
+	// BackendNone runs Args directly, without any sandboxing. Useful on
+	// platforms neither minijail nor nsjail support, or to debug whether
+	// a problem is caused by the sandbox itself.
+	BackendNone
+	// BackendSandboxExec sandboxes via macOS's sandbox-exec, for the
+	// platform minijail0/nsjail don't support at all.
+	BackendSandboxExec
+)
+
+// ParseBackend parses a --sandbox=<mode> flag value into a Backend. The
+// empty string and "auto" both map to BackendAuto, matching the zero
+// value's "pick whatever makes sense" behavior.
+func ParseBackend(s string) (Backend, error) {
+	switch s {
+	case "", "auto":
+		return BackendAuto, nil
+	case "minijail":
+		return BackendMinijail, nil
+	case "nsjail":
+		return BackendNsjail, nil
+	case "sandbox-exec", "sandboxexec":
+		return BackendSandboxExec, nil
+	case "none":
+		return BackendNone, nil
+	default:
+		return BackendAuto, errors.Errorf("unknown sandbox mode %q (expected one of: auto, minijail, nsjail, sandbox-exec, none)", s)
+	}
+}
+
+// Options configures the sandboxed run New creates.
+type Options struct {
+	Args      []string
+	Bindings  []*Binding
+	OutputDir string
+	// SeccompPolicy, if set, restricts the sandboxed process to the
+	// syscalls it allows, on top of whatever namespace/capability
+	// isolation the backend applies by default.
+	SeccompPolicy *SeccompPolicy
+	// Backend selects which sandboxing mechanism to use. The zero value,
+	// BackendAuto, defers to the CIFUZZ_SANDBOX environment variable and
+	// then to whichever backend is registered.
+	Backend Backend
+	// MutatorPlugin, if set, is the path to a libFuzzer mutator plugin
+	// (a "libmutator.so"). The backend adds a read-only Binding for the
+	// plugin file and its containing directory, so the dynamic loader
+	// can still resolve it after chroot, and returns an LD_PRELOAD entry
+	// for it from Runner.Env.
+	MutatorPlugin string
+	// Limits bounds the resources the sandboxed process may use. A nil
+	// Limits uses DefaultLimits; pass &Limits{} explicitly to leave every
+	// resource unbounded.
+	Limits *Limits
+	// OOMScoreAdj, if non-zero, is written to the sandboxed process's
+	// /proc/self/oom_score_adj before it execs the fuzz target, making it
+	// a more (positive values) or less (negative values) likely target
+	// for the kernel's OOM killer than its siblings.
+	OOMScoreAdj int
+}
+
+// Limits configures the rlimits a sandboxed process runs under. A zero
+// field means "don't bound this resource", except where DefaultLimits
+// documents a non-zero default.
+type Limits struct {
+	// AddressSpaceBytes caps RLIMIT_AS. 0 leaves the address space
+	// unbounded, since ASan/MSan's shadow memory reservations can dwarf
+	// a fuzz target's actual working set.
+	AddressSpaceBytes uint64
+	// FileSizeBytes caps RLIMIT_FSIZE, so a fuzz target that starts
+	// writing unbounded output can't fill the host disk.
+	FileSizeBytes uint64
+	// CPUSeconds caps RLIMIT_CPU.
+	CPUSeconds uint64
+	// NoFile caps RLIMIT_NOFILE.
+	NoFile uint64
+	// NProc caps RLIMIT_NPROC, bounding how many processes/threads a fork
+	// bomb inside the sandbox can create.
+	NProc uint64
+	// CoreBytes caps RLIMIT_CORE.
+	CoreBytes uint64
+}
+
+// DefaultLimits is used when Options.Limits is nil. Address space is left
+// unbounded since sanitizers reserve huge, mostly-unused shadow regions;
+// file size is capped at 4 GiB so a runaway fuzz target can't fill the
+// host disk under CI; NoFile is capped at 1024, generous for a fuzz
+// target but still a backstop against fd-exhaustion bugs.
+var DefaultLimits = &Limits{
+	FileSizeBytes: 4 * 1024 * 1024 * 1024,
+	NoFile:        1024,
+}
+
+// ResolveLimits returns opts.Limits if set, else DefaultLimits, so
+// backends don't each have to repeat the nil check.
+func ResolveLimits(limits *Limits) *Limits {
+	if limits == nil {
+		return DefaultLimits
+	}
+	return limits
+}
+
+// MutatorPluginBindings returns the read-only Bindings a MutatorPlugin
+// path needs so the dynamic loader can resolve it from inside the
+// sandbox: one for the plugin file itself, and one for its containing
+// directory, which the loader also consults.
+func MutatorPluginBindings(pluginPath string) ([]*Binding, error) {
+	if pluginPath == "" {
+		return nil, nil
+	}
+	abs, err := filepath.Abs(pluginPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return []*Binding{
+		{Source: abs},
+		{Source: filepath.Dir(abs)},
+	}, nil
+}
+
+// MutatorPluginEnv returns the LD_PRELOAD entry for a MutatorPlugin path,
+// or nil if pluginPath is empty.
+func MutatorPluginEnv(pluginPath string) []string {
+	if pluginPath == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(pluginPath)
+	if err != nil {
+		return nil
+	}
+	return []string{"LD_PRELOAD=" + abs}
+}
+
+// MergeEnv appends additions to env, combining any key both already
+// define (e.g. LD_PRELOAD) into one "KEY=new old" entry, the addition
+// first, instead of
+// leaving two conflicting entries for the same key, since most C
+// runtimes only honor the first one.
+func MergeEnv(env []string, additions []string) []string {
+	merged := append([]string{}, env...)
+	for _, addition := range additions {
+		key, value, ok := strings.Cut(addition, "=")
+		if !ok {
+			merged = append(merged, addition)
+			continue
+		}
+
+		combined := false
+		for i, existing := range merged {
+			existingKey, existingValue, ok := strings.Cut(existing, "=")
+			if !ok || existingKey != key {
+				continue
+			}
+			merged[i] = fmt.Sprintf("%s=%s %s", key, value, existingValue)
+			combined = true
+			break
+		}
+		if !combined {
+			merged = append(merged, addition)
+		}
+	}
+	return merged
+}
+
+// Runner is a sandboxed command ready to be executed: Args is the
+// argument vector (including argv[0]) to exec in place of the original
+// Options.Args, Env is any additional environment variable assignments
+// (e.g. a MutatorPlugin's LD_PRELOAD entry) that should be merged into
+// the child's environment via MergeEnv, and Cleanup releases any
+// resources (e.g. a chroot directory) the backend created for it.
+type Runner interface {
+	Args() []string
+	Env() []string
+	Cleanup()
+}
+
+// Factory constructs a Runner for a registered Backend.
+type Factory func(opts *Options) (Runner, error)
+
+var factories = map[Backend]Factory{}
+
+// Register makes a backend implementation available to New. Backend
+// packages (pkg/minijail, pkg/nsjail) call this from an init function,
+// so importing them for their side effect is what makes New able to
+// dispatch to them.
+func Register(backend Backend, factory Factory) {
+	factories[backend] = factory
+}
+
+// passthrough is the BackendNone Runner: it runs Args unsandboxed.
+type passthrough struct {
+	args []string
+	env  []string
+}
+
+func (p *passthrough) Args() []string { return p.args }
+func (p *passthrough) Env() []string  { return p.env }
+func (p *passthrough) Cleanup()       {}
+
+// backendPreference returns the Backends New tries, in order, when
+// nothing picked one explicitly - minijail0 and nsjail are Linux-only,
+// sandbox-exec is Darwin-only, so the candidate list depends on
+// runtime.GOOS rather than always trying all of them.
+func backendPreference() []Backend {
+	switch runtime.GOOS {
+	case "linux":
+		return []Backend{BackendMinijail, BackendNsjail}
+	case "darwin":
+		return []Backend{BackendSandboxExec}
+	default:
+		return nil
+	}
+}
+
+func backendFromEnv() Backend {
+	backend, err := ParseBackend(os.Getenv("CIFUZZ_SANDBOX"))
+	if err != nil {
+		return BackendAuto
+	}
+	return backend
+}
+
+// New creates a Runner for opts.Args using the backend opts.Backend
+// selects, or CIFUZZ_SANDBOX, or - if neither is set - whichever backend
+// for the current OS is registered.
+func New(opts *Options) (Runner, error) {
+	backend := opts.Backend
+	if backend == BackendAuto {
+		backend = backendFromEnv()
+	}
+
+	if backend == BackendAuto {
+		for _, candidate := range backendPreference() {
+			if _, ok := factories[candidate]; ok {
+				backend = candidate
+				break
+			}
+		}
+	}
+
+	if backend == BackendNone || backend == BackendAuto {
+		return &passthrough{args: opts.Args, env: MutatorPluginEnv(opts.MutatorPlugin)}, nil
+	}
+
+	factory, ok := factories[backend]
+	if !ok {
+		return nil, errors.Errorf("sandbox backend %d is not available (its package was never imported)", backend)
+	}
+	return factory(opts)
+}
@@ -0,0 +1,7 @@
+//go:build !darwin
+
+// Package sandboxexec sandboxes fuzzing and replay runs on macOS using
+// sandbox-exec(1). See sandboxexec_darwin.go for the real implementation;
+// this stub only exists so the package can be blank-imported
+// unconditionally from non-Darwin platforms.
+package sandboxexec
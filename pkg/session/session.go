@@ -0,0 +1,192 @@
+// Package session persists fuzzing run state across invocations of "cifuzz
+// run --session-id", so that a long-running fuzz test can be split across
+// multiple CI jobs (or multiple manual invocations) without losing the
+// accumulated corpus, the elapsed fuzzing time already spent towards
+// --timeout, or the findings seen so far.
+//
+// State is stored as one JSON file per session ID under the XDG state
+// directory, so that it survives across invocations but isn't treated as
+// user data (like the corpus itself) or as a cache (which a user might
+// reasonably expect to be safe to delete without losing fuzzing progress).
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// State is the information persisted for a single session ID between runs.
+type State struct {
+	// SeedCorpusHashes are the SHA-256 hashes of the files in the
+	// generated corpus as of the last save, used to detect which inputs
+	// are new since the session was last resumed.
+	SeedCorpusHashes []string `json:"seedCorpusHashes"`
+	Dictionary       string   `json:"dictionary,omitempty"`
+	EngineArgs       []string `json:"engineArgs,omitempty"`
+	// Findings holds a short description of each finding seen in
+	// previous runs of this session, so that "cifuzz session show"
+	// can report on them without re-running the fuzz test.
+	Findings []string `json:"findings,omitempty"`
+	// Elapsed is the total fuzzing time spent on this session across all
+	// runs, used to compute the remaining --timeout budget on resume.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Dir returns the directory session state files are stored in, creating
+// it if it doesn't exist yet.
+func Dir() (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+
+	dir := filepath.Join(base, "cifuzz", "sessions")
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return dir, nil
+}
+
+// path returns the path of the state file for the given session id,
+// rejecting ids which aren't safe to use as a file name.
+func path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return "", errors.Errorf("invalid session ID %q", id)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, id+".json"), nil
+}
+
+// Load reads the state for the given session id. If no state has been
+// saved for id yet, it returns a zero-value State and no error, so that
+// the first run of a new session doesn't need to special-case "not found".
+func Load(id string) (*State, error) {
+	file, err := path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var state State
+	err = json.Unmarshal(bytes, &state)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &state, nil
+}
+
+// Save writes the state for the given session id, overwriting any
+// previously saved state.
+func Save(id string, state *State) error {
+	file, err := path(id)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.WriteFile(file, bytes, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// List returns the IDs of all sessions with saved state, sorted
+// alphabetically by the underlying directory listing.
+func List() ([]string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+
+	return ids, nil
+}
+
+// HashCorpus returns the SHA-256 hashes, hex-encoded, of every file in
+// dir. It's used to snapshot a generated corpus directory into a State
+// so that the next "cifuzz run --session-id" can tell which inputs were
+// already accounted for in the persisted Elapsed time.
+func HashCorpus(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var hashes []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		hash := sha256.Sum256(content)
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+
+	return hashes, nil
+}
+
+// Remove deletes the saved state for the given session id. It's not an
+// error if no state was saved for id.
+func Remove(id string) error {
+	file, err := path(id)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}